@@ -2,4 +2,6 @@
 
 kubectl delete -f deploy/crds/minio.robotinfra.com_miniobuckets_crd.yaml
 kubectl delete -f deploy/crds/minio.robotinfra.com_miniousers_crd.yaml
-kubectl delete -f deploy/crds/minio.robotinfra.com_minioservers_crd.yaml
\ No newline at end of file
+kubectl delete -f deploy/crds/minio.robotinfra.com_minioservers_crd.yaml
+kubectl delete -f deploy/crds/minio.robotinfra.com_miniopolicies_crd.yaml
+kubectl delete -f deploy/crds/minio.robotinfra.com_miniogroups_crd.yaml
@@ -4,4 +4,6 @@ set -e
 
 kubectl create -f deploy/crds/minio.robotinfra.com_miniousers_crd.yaml
 kubectl create -f deploy/crds/minio.robotinfra.com_miniobuckets_crd.yaml
-kubectl create -f deploy/crds/minio.robotinfra.com_minioservers_crd.yaml
\ No newline at end of file
+kubectl create -f deploy/crds/minio.robotinfra.com_minioservers_crd.yaml
+kubectl create -f deploy/crds/minio.robotinfra.com_miniopolicies_crd.yaml
+kubectl create -f deploy/crds/minio.robotinfra.com_miniogroups_crd.yaml
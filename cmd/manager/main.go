@@ -24,6 +24,8 @@ import (
 
 	"github.com/robotinfra/minio-resources-operator/pkg/apis"
 	"github.com/robotinfra/minio-resources-operator/pkg/controller"
+	miniometrics "github.com/robotinfra/minio-resources-operator/pkg/metrics"
+	"github.com/robotinfra/minio-resources-operator/pkg/webhook"
 	"github.com/robotinfra/minio-resources-operator/version"
 )
 
@@ -33,6 +35,13 @@ var (
 	metricsPort         int32 = 8383
 	operatorMetricsPort int32 = 8686
 )
+
+// Validating webhooks options
+var (
+	enableWebhooks = pflag.Bool("enable-webhooks", false, "Serve the validating admission webhooks")
+	webhookPort    = pflag.Int("webhook-port", 9443, "Port of the validating admission webhooks server")
+	webhookCertDir = pflag.String("webhook-cert-dir", "", "Directory holding tls.crt and tls.key of the validating admission webhooks server")
+)
 var log = logf.Log.WithName("cmd")
 
 func printVersion() {
@@ -90,6 +99,8 @@ func main() {
 	mgr, err := manager.New(cfg, manager.Options{
 		Namespace:          namespace,
 		MetricsBindAddress: fmt.Sprintf("%s:%d", metricsHost, metricsPort),
+		Port:               *webhookPort,
+		CertDir:            *webhookCertDir,
 	})
 	if err != nil {
 		log.Error(err, "")
@@ -110,6 +121,20 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup metrics of Minio resources, served with the controller-runtime metrics
+	if err := miniometrics.RegisterResources(mgr.GetClient()); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+
+	// Setup validating webhooks
+	if *enableWebhooks {
+		if err := webhook.AddToManager(mgr); err != nil {
+			log.Error(err, "")
+			os.Exit(1)
+		}
+	}
+
 	// Add the Metrics Service
 	if len(os.Getenv("DOCKER_DEVELOPMENT")) == 0 {
 		if namespace, err = k8sutil.GetOperatorNamespace(); err != nil {
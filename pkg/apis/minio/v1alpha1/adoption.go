@@ -0,0 +1,33 @@
+package v1alpha1
+
+// AdoptAnnotation allow a resource to manage an existing Minio bucket, user, canned policy or group when set to "true",
+// as an alternative to spec.adopt
+const AdoptAnnotation = "minio.robotinfra.com/adopt"
+
+// OwnerTag is the bucket tag recording the MinioBucket owning a bucket, as namespace/name
+const OwnerTag = "minio.robotinfra.com/owner"
+
+// ShouldAdopt return true if the MinioBucket may manage an existing bucket
+func (mb *MinioBucket) ShouldAdopt() bool {
+	return mb.Spec.Adopt || mb.GetAnnotations()[AdoptAnnotation] == "true"
+}
+
+// GetOwnerTag return the value of the owner tag of buckets owned by the MinioBucket
+func (mb *MinioBucket) GetOwnerTag() string {
+	return mb.Namespace + "/" + mb.Name
+}
+
+// ShouldAdopt return true if the MinioUser may manage an existing Minio user
+func (mu *MinioUser) ShouldAdopt() bool {
+	return mu.Spec.Adopt || mu.GetAnnotations()[AdoptAnnotation] == "true"
+}
+
+// ShouldAdopt return true if the MinioPolicy may manage an existing canned policy
+func (mp *MinioPolicy) ShouldAdopt() bool {
+	return mp.Spec.Adopt || mp.GetAnnotations()[AdoptAnnotation] == "true"
+}
+
+// ShouldAdopt return true if the MinioGroup may manage an existing Minio group
+func (mg *MinioGroup) ShouldAdopt() bool {
+	return mg.Spec.Adopt || mg.GetAnnotations()[AdoptAnnotation] == "true"
+}
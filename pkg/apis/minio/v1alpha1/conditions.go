@@ -0,0 +1,102 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionType is the type of a condition
+type ConditionType string
+
+// Condition types
+const (
+	// ConditionReady is true when the resource is reconciled
+	ConditionReady ConditionType = "Ready"
+	// ConditionServerReachable is true when the MinioServer can be reached
+	ConditionServerReachable ConditionType = "ServerReachable"
+	// ConditionPolicyApplied is true when the policy is applied on the Minio server
+	ConditionPolicyApplied ConditionType = "PolicyApplied"
+	// ConditionMembersSynced is true when all the group members are in the Minio group
+	ConditionMembersSynced ConditionType = "MembersSynced"
+	// ConditionVersioningApplied is true when the bucket versioning is in the desired state
+	ConditionVersioningApplied ConditionType = "VersioningApplied"
+	// ConditionLifecycleApplied is true when the bucket lifecycle configuration is applied
+	ConditionLifecycleApplied ConditionType = "LifecycleApplied"
+	// ConditionNotificationsApplied is true when the bucket notifications are applied
+	ConditionNotificationsApplied ConditionType = "NotificationsApplied"
+	// ConditionUsageCollected is true when the bucket usage was collected
+	ConditionUsageCollected ConditionType = "UsageCollected"
+	// ConditionConflict is true when the Minio resource exists but is not owned by the resource
+	ConditionConflict ConditionType = "Conflict"
+)
+
+// Condition is an observation of the state of a resource
+type Condition struct {
+	Type               ConditionType          `json:"type"`
+	Status             corev1.ConditionStatus `json:"status"`
+	LastTransitionTime metav1.Time            `json:"lastTransitionTime,omitempty"`
+	Reason             string                 `json:"reason,omitempty"`
+	Message            string                 `json:"message,omitempty"`
+}
+
+// Conditions is a list of conditions, with at most one condition per type
+type Conditions []Condition
+
+// GetCondition return the condition of a type, nil if not found
+func (c Conditions) GetCondition(t ConditionType) *Condition {
+	for i := range c {
+		if c[i].Type == t {
+			return &c[i]
+		}
+	}
+	return nil
+}
+
+// IsTrueFor return true if the condition of a type is true
+func (c Conditions) IsTrueFor(t ConditionType) bool {
+	condition := c.GetCondition(t)
+	return condition != nil && condition.Status == corev1.ConditionTrue
+}
+
+// SetCondition add or replace the condition of the same type.
+// LastTransitionTime is only changed when the status changes.
+func (c *Conditions) SetCondition(t ConditionType, status corev1.ConditionStatus, reason, message string) {
+	existing := c.GetCondition(t)
+	if existing == nil {
+		*c = append(*c, Condition{
+			Type:               t,
+			Status:             status,
+			LastTransitionTime: metav1.Now(),
+			Reason:             reason,
+			Message:            message,
+		})
+		return
+	}
+	if existing.Status != status {
+		existing.Status = status
+		existing.LastTransitionTime = metav1.Now()
+	}
+	existing.Reason = reason
+	existing.Message = message
+}
+
+// IsReconcileFailedWith return true if the Ready condition reports a failed reconciliation with the same error
+func (c Conditions) IsReconcileFailedWith(err error) bool {
+	condition := c.GetCondition(ConditionReady)
+	return condition != nil && condition.Status == corev1.ConditionFalse && condition.Reason == "ReconcileFailed" && condition.Message == err.Error()
+}
+
+// SetReconcileFailed set the Ready condition to false with the error of a failed reconciliation
+func (c *Conditions) SetReconcileFailed(err error) {
+	c.SetCondition(ConditionReady, corev1.ConditionFalse, "ReconcileFailed", err.Error())
+}
+
+// RemoveCondition remove the condition of a type
+func (c *Conditions) RemoveCondition(t ConditionType) {
+	for i := range *c {
+		if (*c)[i].Type == t {
+			*c = append((*c)[:i], (*c)[i+1:]...)
+			return
+		}
+	}
+}
@@ -9,10 +9,135 @@ type MinioBucketSpec struct {
 	Server string `json:"server"`
 	Name   string `json:"name"`
 	Policy string `json:"policy,omitempty"`
+	// Rules of the bucket policy, merged with the inline policy
+	PolicyRules []PolicyRule `json:"policyRules,omitempty"`
+	// Predefined anonymous accesses to the bucket, merged with the inline policy
+	PolicyPresets []PolicyPreset `json:"policyPresets,omitempty"`
+	// Versioning state of the bucket, left unchanged when not set
+	Versioning VersioningStatus `json:"versioning,omitempty"`
+	// Lifecycle configuration of the bucket, left unchanged when not set
+	Lifecycle *Lifecycle `json:"lifecycle,omitempty"`
+	// Event notifications of the bucket, left unchanged when not set
+	Notifications *Notifications `json:"notifications,omitempty"`
+	// What happens to the bucket when the MinioBucket is deleted, Delete by default
+	DeletionPolicy BucketDeletionPolicy `json:"deletionPolicy,omitempty"`
+	// Manage the bucket if it already exists and is not owned by another MinioBucket
+	Adopt bool `json:"adopt,omitempty"`
+}
+
+// BucketDeletionPolicy is what happens to a bucket when its MinioBucket is deleted
+// +kubebuilder:validation:Enum=Retain;Delete;Purge
+type BucketDeletionPolicy string
+
+// Bucket deletion policies
+const (
+	// BucketDeletionRetain leave the bucket and its objects on the Minio server
+	BucketDeletionRetain BucketDeletionPolicy = "Retain"
+	// BucketDeletionDelete remove the bucket, only if it is empty
+	BucketDeletionDelete BucketDeletionPolicy = "Delete"
+	// BucketDeletionPurge remove all objects, versions and incomplete uploads, then the bucket
+	BucketDeletionPurge BucketDeletionPolicy = "Purge"
+)
+
+// VersioningStatus is the versioning state of a bucket
+// +kubebuilder:validation:Enum=Enabled;Suspended
+type VersioningStatus string
+
+// Versioning states of a bucket
+const (
+	VersioningEnabled   VersioningStatus = "Enabled"
+	VersioningSuspended VersioningStatus = "Suspended"
+)
+
+// Lifecycle is the lifecycle configuration of a bucket
+type Lifecycle struct {
+	// Rules of the bucket lifecycle, the lifecycle configuration is removed when empty
+	Rules []LifecycleRule `json:"rules,omitempty"`
+}
+
+// LifecycleRule is a lifecycle rule, applied to objects matching its prefix and tags
+type LifecycleRule struct {
+	// Unique identifier of the rule
+	// +kubebuilder:validation:MaxLength=255
+	ID string `json:"id"`
+	// Disable the rule
+	Disabled bool `json:"disabled,omitempty"`
+	// Prefix of the objects the rule applies to
+	Prefix string `json:"prefix,omitempty"`
+	// Tags of the objects the rule applies to
+	Tags map[string]string `json:"tags,omitempty"`
+	// Expiration of the current version of objects
+	Expiration *LifecycleExpiration `json:"expiration,omitempty"`
+	// Number of days after which non current versions of objects are removed
+	// +kubebuilder:validation:Minimum=1
+	NoncurrentVersionExpirationDays int32 `json:"noncurrentVersionExpirationDays,omitempty"`
+	// Number of days after which incomplete multipart uploads are aborted
+	// +kubebuilder:validation:Minimum=1
+	AbortIncompleteMultipartUploadDays int32 `json:"abortIncompleteMultipartUploadDays,omitempty"`
+}
+
+// LifecycleExpiration expire objects after a number of days or at a date
+type LifecycleExpiration struct {
+	// Number of days after object creation
+	// +kubebuilder:validation:Minimum=1
+	Days int32 `json:"days,omitempty"`
+	// Date at midnight UTC, such as 2020-12-31T00:00:00Z
+	Date *metav1.Time `json:"date,omitempty"`
+}
+
+// Notifications are the event notifications of a bucket
+type Notifications struct {
+	// Rules of the bucket notifications, notifications not in rules are removed
+	Rules []NotificationRule `json:"rules,omitempty"`
+}
+
+// NotificationRule send events of objects matching a prefix and suffix to a target
+type NotificationRule struct {
+	// ARN of the notification target configured on the Minio server, such as arn:minio:sqs::1:webhook
+	// +kubebuilder:validation:Pattern=`^arn:[^:]*:[^:]+:[^:]*:[^:]*:.+$`
+	Arn string `json:"arn"`
+	// Events sent to the target, such as s3:ObjectCreated:*
+	// +kubebuilder:validation:MinItems=1
+	Events []string `json:"events"`
+	// Prefix of the objects names
+	Prefix string `json:"prefix,omitempty"`
+	// Suffix of the objects names
+	Suffix string `json:"suffix,omitempty"`
 }
 
 // MinioBucketStatus defines the observed state of MinioBucket
 type MinioBucketStatus struct {
+	// Generation of the MinioBucket last reconciled
+	ObservedGeneration int64      `json:"observedGeneration,omitempty"`
+	Conditions         Conditions `json:"conditions,omitempty"`
+	// SHA-256 of the policy applied on the bucket
+	PolicyHash string `json:"policyHash,omitempty"`
+	// Creation time of the bucket on the Minio server
+	CreationTime *metav1.Time `json:"creationTime,omitempty"`
+	// Versioning state of the bucket on the Minio server, empty if never enabled
+	Versioning string `json:"versioning,omitempty"`
+	// Progress of the bucket purge, when deleted with the Purge deletion policy
+	Purge *BucketPurgeStatus `json:"purge,omitempty"`
+	// Usage of the bucket, when collected for the MinioServer
+	Usage *BucketUsage `json:"usage,omitempty"`
+}
+
+// BucketUsage is the usage of a bucket on the Minio server
+type BucketUsage struct {
+	// Total size of the objects in bytes, as last computed by the Minio server
+	Size int64 `json:"size"`
+	// Time of the collection
+	LastUpdateTime metav1.Time `json:"lastUpdateTime"`
+}
+
+// BucketPurgeStatus is the progress of a bucket purge
+type BucketPurgeStatus struct {
+	// Number of objects removed
+	RemovedObjects int64 `json:"removedObjects"`
+	// Number of object versions and delete markers removed
+	RemovedVersions int64 `json:"removedVersions"`
+	// Number of objects with incomplete multipart uploads aborted
+	AbortedUploads int64 `json:"abortedUploads"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -20,6 +145,14 @@ type MinioBucketStatus struct {
 // MinioBucket is the Schema for the miniobuckets API
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:path=miniobuckets,scope=Namespaced
+// +kubebuilder:printcolumn:name="Bucket",type="string",JSONPath=".spec.name"
+// +kubebuilder:printcolumn:name="Server",type="string",JSONPath=".spec.server"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason",priority=1
+// +kubebuilder:printcolumn:name="Versioning",type="string",JSONPath=".status.versioning",priority=1
+// +kubebuilder:printcolumn:name="Created",type="date",JSONPath=".status.creationTime",priority=1
+// +kubebuilder:printcolumn:name="Size",type="integer",JSONPath=".status.usage.size",priority=1
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type MinioBucket struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -0,0 +1,66 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MinioGroupSpec defines the desired state of MinioGroup
+type MinioGroupSpec struct {
+	Server string `json:"server"`
+	// Name of the group on the Minio server
+	Name string `json:"name"`
+	// Names of MinioUser in the MinioGroup namespace, members of the group
+	Users []string `json:"users,omitempty"`
+	// Access keys of Minio users members of the group, not managed by a MinioUser
+	AccessKeys []string `json:"accessKeys,omitempty"`
+	// Name of the canned policy attached to the group
+	Policy string `json:"policy,omitempty"`
+	// Disable the group on the Minio server
+	Disabled bool `json:"disabled,omitempty"`
+	// Manage the Minio group if it already exists and is not owned by another MinioGroup
+	Adopt bool `json:"adopt,omitempty"`
+}
+
+// MinioGroupStatus defines the observed state of MinioGroup
+type MinioGroupStatus struct {
+	// Generation of the MinioGroup last reconciled
+	ObservedGeneration int64      `json:"observedGeneration,omitempty"`
+	Conditions         Conditions `json:"conditions,omitempty"`
+	// Access keys of the group members on the Minio server
+	Members []string `json:"members,omitempty"`
+	// Status of the group on the Minio server, enabled or disabled
+	GroupStatus string `json:"groupStatus,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MinioGroup is the Schema for the miniogroups API
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=miniogroups,scope=Namespaced
+// +kubebuilder:printcolumn:name="Group",type="string",JSONPath=".spec.name"
+// +kubebuilder:printcolumn:name="Server",type="string",JSONPath=".spec.server"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.groupStatus"
+// +kubebuilder:printcolumn:name="Policy",type="string",JSONPath=".spec.policy",priority=1
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason",priority=1
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type MinioGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MinioGroupSpec   `json:"spec,omitempty"`
+	Status MinioGroupStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MinioGroupList contains a list of MinioGroup
+type MinioGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MinioGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MinioGroup{}, &MinioGroupList{})
+}
@@ -0,0 +1,14 @@
+package v1alpha1
+
+// BuiltinPolicies are the canned policies created by Minio, used by users and groups outside of the operator
+var BuiltinPolicies = []string{"readonly", "readwrite", "writeonly"}
+
+// IsBuiltinPolicy return true if a canned policy name is a built-in Minio policy
+func IsBuiltinPolicy(name string) bool {
+	for _, builtin := range BuiltinPolicies {
+		if name == builtin {
+			return true
+		}
+	}
+	return false
+}
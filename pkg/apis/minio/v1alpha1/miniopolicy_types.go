@@ -0,0 +1,53 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MinioPolicySpec defines the desired state of MinioPolicy
+type MinioPolicySpec struct {
+	Server string `json:"server"`
+	// Name of the canned policy on the Minio server
+	Name   string `json:"name"`
+	Policy string `json:"policy"`
+	// Manage the canned policy if it already exists and is not owned by another MinioPolicy
+	Adopt bool `json:"adopt,omitempty"`
+}
+
+// MinioPolicyStatus defines the observed state of MinioPolicy
+type MinioPolicyStatus struct {
+	// Generation of the MinioPolicy last reconciled
+	ObservedGeneration int64      `json:"observedGeneration,omitempty"`
+	Conditions         Conditions `json:"conditions,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MinioPolicy is the Schema for the miniopolicies API
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=miniopolicies,scope=Namespaced
+// +kubebuilder:printcolumn:name="Policy",type="string",JSONPath=".spec.name"
+// +kubebuilder:printcolumn:name="Server",type="string",JSONPath=".spec.server"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason",priority=1
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type MinioPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MinioPolicySpec   `json:"spec,omitempty"`
+	Status MinioPolicyStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MinioPolicyList contains a list of MinioPolicy
+type MinioPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MinioPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MinioPolicy{}, &MinioPolicyList{})
+}
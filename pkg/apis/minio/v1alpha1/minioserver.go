@@ -6,3 +6,17 @@ import "fmt"
 func (ms *MinioServerSpec) GetHostname() string {
 	return fmt.Sprintf("%s:%d", ms.Hostname, ms.Port)
 }
+
+// GetEndpointURL return the URL of the minio server
+func (ms *MinioServerSpec) GetEndpointURL() string {
+	scheme := "http"
+	if ms.SSL {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, ms.GetHostname())
+}
+
+// IsOffline return true if the last health check of the minio server failed
+func (ms *MinioServer) IsOffline() bool {
+	return ms.Status.LastProbeTime != nil && !ms.Status.Online
+}
@@ -1,21 +1,73 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // MinioServerSpec defines the desired state of MinioServer
 type MinioServerSpec struct {
-	Hostname  string `json:"hostname"`
-	Port      int    `json:"port"`
-	SSL       bool   `json:"ssl,omitempty"`
-	AccessKey string `json:"accessKey"`
-	SecretKey string `json:"secretKey"`
+	Hostname string `json:"hostname"`
+	Port     int    `json:"port"`
+	SSL      bool   `json:"ssl,omitempty"`
+	// Deprecated: use CredentialsSecretRef instead.
+	AccessKey string `json:"accessKey,omitempty"`
+	// Deprecated: use CredentialsSecretRef instead.
+	SecretKey            string                      `json:"secretKey,omitempty"`
+	CredentialsSecretRef *CredentialsSecretReference `json:"credentialsSecretRef,omitempty"`
+	// TLS options of connections to the server, used when SSL is enabled
+	TLS *TLSConfig `json:"tls,omitempty"`
+	// Timeout of requests to the server, default to 30s
+	RequestTimeout *metav1.Duration `json:"requestTimeout,omitempty"`
+	// Interval between two collections of the usage of MinioBucket, at least 1m, disabled when not set
+	BucketUsageInterval *metav1.Duration `json:"bucketUsageInterval,omitempty"`
+}
+
+// TLSConfig defines TLS options of connections to a MinioServer
+type TLSConfig struct {
+	// PEM encoded CA certificates used to verify the server certificate, in addition to system ones
+	CABundle string `json:"caBundle,omitempty"`
+	// Secret holding PEM encoded CA certificates used to verify the server certificate, key default to ca.crt
+	CASecretRef *SecretKeyReference `json:"caSecretRef,omitempty"`
+	// Don't verify the server certificate
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+	// Secret of type kubernetes.io/tls holding a client certificate for mutual TLS
+	ClientCertSecretRef *corev1.SecretReference `json:"clientCertSecretRef,omitempty"`
+	// Server name used to verify the server certificate, default to hostname
+	ServerName string `json:"serverName,omitempty"`
+}
+
+// SecretKeyReference points to a key of a Secret
+type SecretKeyReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Key       string `json:"key,omitempty"`
+}
+
+// CredentialsSecretReference points to a Secret holding the admin credentials of a MinioServer
+type CredentialsSecretReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// Key of the access key in the Secret, default to accessKey
+	AccessKeyKey string `json:"accessKeyKey,omitempty"`
+	// Key of the secret key in the Secret, default to secretKey
+	SecretKeyKey string `json:"secretKeyKey,omitempty"`
 }
 
 // MinioServerStatus defines the observed state of MinioServer
 type MinioServerStatus struct {
 	Online bool `json:"online"`
+	// Minio version of the first node
+	Version string `json:"version,omitempty"`
+	Region  string `json:"region,omitempty"`
+	// Number of nodes of the Minio server
+	Nodes int `json:"nodes,omitempty"`
+	// Number of drives of all nodes
+	Drives int `json:"drives,omitempty"`
+	// Time of the last health check
+	LastProbeTime *metav1.Time `json:"lastProbeTime,omitempty"`
+	// Error of the last health check, empty if it succeeded
+	LastError string `json:"lastError,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -23,6 +75,14 @@ type MinioServerStatus struct {
 // MinioServer is the Schema for the minioservers API
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:path=minioservers,scope=Cluster
+// +kubebuilder:printcolumn:name="Hostname",type="string",JSONPath=".spec.hostname"
+// +kubebuilder:printcolumn:name="Port",type="integer",JSONPath=".spec.port"
+// +kubebuilder:printcolumn:name="Online",type="boolean",JSONPath=".status.online"
+// +kubebuilder:printcolumn:name="Version",type="string",JSONPath=".status.version"
+// +kubebuilder:printcolumn:name="Nodes",type="integer",JSONPath=".status.nodes",priority=1
+// +kubebuilder:printcolumn:name="Drives",type="integer",JSONPath=".status.drives",priority=1
+// +kubebuilder:printcolumn:name="Error",type="string",JSONPath=".status.lastError",priority=1
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type MinioServer struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -0,0 +1,19 @@
+package v1alpha1
+
+import "fmt"
+
+// GeneratedSecretKeyKey is the key holding the secret key in generated Secrets
+const GeneratedSecretKeyKey = "secretKey"
+
+// GeneratedPolicyPrefix is the prefix of the canned policies generated for MinioUser, reserved to them
+const GeneratedPolicyPrefix = "_generator_"
+
+// GetGeneratedSecretName return the name of the Secret holding the generated secret key
+func (mu *MinioUser) GetGeneratedSecretName() string {
+	return fmt.Sprintf("%s-minio-user", mu.Name)
+}
+
+// GetGeneratedPolicyName return the name of the canned policy generated for the MinioUser
+func (mu *MinioUser) GetGeneratedPolicyName() string {
+	return GeneratedPolicyPrefix + mu.Spec.AccessKey
+}
@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -8,12 +9,77 @@ import (
 type MinioUserSpec struct {
 	Server    string `json:"server"`
 	AccessKey string `json:"accessKey"`
-	SecretKey string `json:"secretKey"`
-	Policy    string `json:"policy,omitempty"`
+	SecretKey string `json:"secretKey,omitempty"`
+	// Read secret key from a Secret in the MinioUser namespace.
+	// If neither secretKey or secretKeyRef are set, a secret key is generated.
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+	Policy       string                    `json:"policy,omitempty"`
+	// Names of canned policies attached to the user, such as the ones of MinioPolicy.
+	// They are attached next to the generated policy of the inline policy and rules.
+	Policies []string `json:"policies,omitempty"`
+	// Rules of the user policy, merged with the inline policy
+	PolicyRules []PolicyRule `json:"policyRules,omitempty"`
+	// Predefined accesses to buckets, merged with the inline policy
+	PolicyPresets []PolicyPreset `json:"policyPresets,omitempty"`
+	// Write connection informations of the MinioUser in a Secret
+	WriteConnectionSecretToRef *ConnectionSecret `json:"writeConnectionSecretToRef,omitempty"`
+	// What happens to the Minio user when the MinioUser is deleted, Delete by default
+	DeletionPolicy UserDeletionPolicy `json:"deletionPolicy,omitempty"`
+	// Manage the Minio user if it already exists and is not owned by another MinioUser
+	Adopt bool `json:"adopt,omitempty"`
+}
+
+// UserDeletionPolicy is what happens to a Minio user when its MinioUser is deleted
+// +kubebuilder:validation:Enum=Delete;Retain;Disable
+type UserDeletionPolicy string
+
+// User deletion policies
+const (
+	// UserDeletionDelete remove the Minio user and its generated canned policy
+	UserDeletionDelete UserDeletionPolicy = "Delete"
+	// UserDeletionRetain leave the Minio user and its generated canned policy
+	UserDeletionRetain UserDeletionPolicy = "Retain"
+	// UserDeletionDisable disable the Minio user, and leave its generated canned policy
+	UserDeletionDisable UserDeletionPolicy = "Disable"
+)
+
+// ConnectionSecretFormat is a format of the keys of a connection Secret
+// +kubebuilder:validation:Enum=plain;aws;s3cmd;mc
+type ConnectionSecretFormat string
+
+// Connection Secret formats
+const (
+	// ConnectionSecretFormatPlain write endpoint, port, ssl, accessKey, secretKey and bucket keys
+	ConnectionSecretFormatPlain ConnectionSecretFormat = "plain"
+	// ConnectionSecretFormatAWS write AWS_ENDPOINT_URL, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY keys
+	ConnectionSecretFormatAWS ConnectionSecretFormat = "aws"
+	// ConnectionSecretFormatS3cmd write a s3cmd configuration file in the .s3cfg key
+	ConnectionSecretFormatS3cmd ConnectionSecretFormat = "s3cmd"
+	// ConnectionSecretFormatMc write a mc configuration file in the config.json key
+	ConnectionSecretFormatMc ConnectionSecretFormat = "mc"
+)
+
+// ConnectionSecret defines the Secret holding the connection informations of a MinioUser
+type ConnectionSecret struct {
+	// Name of the Secret, in the MinioUser namespace
+	Name string `json:"name"`
+	// Formats of the Secret keys, default to plain
+	Formats []ConnectionSecretFormat `json:"formats,omitempty"`
+	// Default bucket of the application
+	Bucket string `json:"bucket,omitempty"`
 }
 
 // MinioUserStatus defines the observed state of MinioUser
 type MinioUserStatus struct {
+	// Generation of the MinioUser last reconciled
+	ObservedGeneration int64      `json:"observedGeneration,omitempty"`
+	Conditions         Conditions `json:"conditions,omitempty"`
+	// Name of the canned policy attached to the user
+	PolicyName string `json:"policyName,omitempty"`
+	// Status of the account on the Minio server, enabled or disabled
+	AccountStatus string `json:"accountStatus,omitempty"`
+	// Error of the last reconciliation, empty if it succeeded
+	LastError string `json:"lastError,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -21,6 +87,13 @@ type MinioUserStatus struct {
 // MinioUser is the Schema for the miniousers API
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:path=miniousers,scope=Namespaced
+// +kubebuilder:printcolumn:name="Access Key",type="string",JSONPath=".spec.accessKey"
+// +kubebuilder:printcolumn:name="Server",type="string",JSONPath=".spec.server"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Account",type="string",JSONPath=".status.accountStatus"
+// +kubebuilder:printcolumn:name="Policy",type="string",JSONPath=".status.policyName",priority=1
+// +kubebuilder:printcolumn:name="Error",type="string",JSONPath=".status.lastError",priority=1
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type MinioUser struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -0,0 +1,63 @@
+package v1alpha1
+
+// PolicyEffect is the effect of a policy rule
+// +kubebuilder:validation:Enum=Allow;Deny
+type PolicyEffect string
+
+// Policy rule effects
+const (
+	PolicyEffectAllow PolicyEffect = "Allow"
+	PolicyEffectDeny  PolicyEffect = "Deny"
+)
+
+// PolicyPresetAccess is the access granted by a policy preset
+// +kubebuilder:validation:Enum=readOnly;readWrite;writeOnly
+type PolicyPresetAccess string
+
+// Policy preset accesses
+const (
+	// PolicyPresetReadOnly allow to list buckets and download objects
+	PolicyPresetReadOnly PolicyPresetAccess = "readOnly"
+	// PolicyPresetReadWrite allow to list buckets, download, upload and remove objects
+	PolicyPresetReadWrite PolicyPresetAccess = "readWrite"
+	// PolicyPresetWriteOnly allow to upload objects
+	PolicyPresetWriteOnly PolicyPresetAccess = "writeOnly"
+)
+
+// PolicyRule is a statement of a policy document
+type PolicyRule struct {
+	// Effect of the rule, Allow by default
+	Effect PolicyEffect `json:"effect,omitempty"`
+	// Actions of the rule, such as s3:GetObject
+	// +kubebuilder:validation:MinItems=1
+	Actions []string `json:"actions"`
+	// Buckets the rule applies to, all buckets of a MinioUser or the bucket of a MinioBucket by default
+	Buckets []string `json:"buckets,omitempty"`
+	// Prefixes of the objects the rule applies to, all objects by default
+	Prefixes []string `json:"prefixes,omitempty"`
+	// Principals the rule applies to in a bucket policy, everyone by default, ignored by MinioUser
+	Principals []string `json:"principals,omitempty"`
+	// Conditions of the rule
+	Conditions []PolicyCondition `json:"conditions,omitempty"`
+}
+
+// PolicyCondition is a condition of a policy rule
+type PolicyCondition struct {
+	// Condition operator, such as StringLike or IpAddress
+	Operator string `json:"operator"`
+	// Condition key, such as s3:prefix or aws:SourceIp
+	Key string `json:"key"`
+	// Values compared to the key
+	// +kubebuilder:validation:MinItems=1
+	Values []string `json:"values"`
+}
+
+// PolicyPreset grant a predefined access to buckets
+type PolicyPreset struct {
+	// Access granted to the buckets
+	Access PolicyPresetAccess `json:"access"`
+	// Buckets the access is granted to, the bucket of a MinioBucket by default
+	Buckets []string `json:"buckets,omitempty"`
+	// Prefixes of the objects the access is granted to, all objects by default
+	Prefixes []string `json:"prefixes,omitempty"`
+}
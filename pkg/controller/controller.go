@@ -2,15 +2,24 @@ package controller
 
 import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/robotinfra/minio-resources-operator/pkg/minioclient"
 )
 
 // AddToManagerFuncs is a list of functions to add all Controllers to the Manager
-var AddToManagerFuncs []func(manager.Manager) error
+var AddToManagerFuncs []func(manager.Manager, minioclient.Clients) error
 
-// AddToManager adds all Controllers to the Manager
+// AddToManager adds all Controllers to the Manager, sharing a pool of Minio clients
 func AddToManager(m manager.Manager) error {
+	return AddToManagerWithClients(m, minioclient.NewPool(m.GetClient()))
+}
+
+// AddToManagerWithClients adds all Controllers to the Manager, accessing Minio servers with minioClients
+// instrumented with metrics
+func AddToManagerWithClients(m manager.Manager, minioClients minioclient.Clients) error {
+	minioClients = minioclient.Instrument(minioClients)
 	for _, f := range AddToManagerFuncs {
-		if err := f(m); err != nil {
+		if err := f(m, minioClients); err != nil {
 			return err
 		}
 	}
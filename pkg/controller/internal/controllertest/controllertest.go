@@ -0,0 +1,152 @@
+// Package controllertest provides the fixtures and assertions shared by the tests of the controllers
+package controllertest
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/robotinfra/minio-resources-operator/pkg/apis"
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	miniofake "github.com/robotinfra/minio-resources-operator/pkg/minioclient/fake"
+	"github.com/robotinfra/minio-resources-operator/pkg/utils"
+)
+
+// NewClient return a fake Kubernetes client with objects, and its scheme of Kubernetes and Minio resources
+func NewClient(t *testing.T, objs ...runtime.Object) (client.Client, *runtime.Scheme) {
+	t.Helper()
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatalf("clientgoscheme.AddToScheme: %v", err)
+	}
+	if err := apis.AddToScheme(s); err != nil {
+		t.Fatalf("apis.AddToScheme: %v", err)
+	}
+	return fakeclient.NewFakeClientWithScheme(s, objs...), s
+}
+
+// NewMinioServer return the MinioServer test
+func NewMinioServer() *miniov1alpha1.MinioServer {
+	return &miniov1alpha1.MinioServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", UID: "server-uid"},
+		Spec:       miniov1alpha1.MinioServerSpec{Hostname: "minio.example.com", Port: 9000},
+	}
+}
+
+// ReconcileOK reconcile a request, and fail on error
+func ReconcileOK(t *testing.T, r reconcile.Reconciler, request reconcile.Request) reconcile.Result {
+	t.Helper()
+	result, err := r.Reconcile(request)
+	if err != nil {
+		t.Fatalf("r.Reconcile: %v", err)
+	}
+	return result
+}
+
+// Get read the object of a request in obj, and fail on error
+func Get(t *testing.T, c client.Client, request reconcile.Request, obj runtime.Object) {
+	t.Helper()
+	if err := c.Get(context.TODO(), request.NamespacedName, obj); err != nil {
+		t.Fatalf("c.Get: %v", err)
+	}
+}
+
+// Update update an object, and fail on error
+func Update(t *testing.T, c client.Client, obj runtime.Object) {
+	t.Helper()
+	if err := c.Update(context.TODO(), obj); err != nil {
+		t.Fatalf("c.Update: %v", err)
+	}
+}
+
+// AssertCondition fail if a condition doesn't have a status and reason
+func AssertCondition(t *testing.T, conditions miniov1alpha1.Conditions, conditionType miniov1alpha1.ConditionType, status corev1.ConditionStatus, reason string) {
+	t.Helper()
+	condition := conditions.GetCondition(conditionType)
+	if condition == nil {
+		t.Fatalf("condition %s not found", conditionType)
+	}
+	if condition.Status != status || condition.Reason != reason {
+		t.Fatalf("condition %s is %s/%s, expected %s/%s", conditionType, condition.Status, condition.Reason, status, reason)
+	}
+}
+
+// AssertConflict fail if the conditions don't report a resource not ready because of a conflict with a reason
+func AssertConflict(t *testing.T, conditions miniov1alpha1.Conditions, reason string) {
+	t.Helper()
+	AssertCondition(t, conditions, miniov1alpha1.ConditionConflict, corev1.ConditionTrue, reason)
+	AssertCondition(t, conditions, miniov1alpha1.ConditionReady, corev1.ConditionFalse, "Conflict")
+}
+
+// AssertFinalizer fail if the presence of a finalizer on an object is not the expected one
+func AssertFinalizer(t *testing.T, obj metav1.Object, finalizer string, expected bool) {
+	t.Helper()
+	if present := utils.Contains(obj.GetFinalizers(), finalizer); present != expected {
+		t.Errorf("finalizer %s present is %v, expected %v", finalizer, present, expected)
+	}
+}
+
+// AssertCallCount fail if a method of the fake Minio server was not called the expected number of times
+func AssertCallCount(t *testing.T, server *miniofake.Server, method string, expected int) {
+	t.Helper()
+	if count := server.CallCount(method); count != expected {
+		t.Errorf("%s called %d times, expected %d", method, count, expected)
+	}
+}
+
+// RecordedEvents return and forget the events recorded by a fake recorder, as "type reason"
+func RecordedEvents(recorder record.EventRecorder) []string {
+	events := []string{}
+	fakeRecorder := recorder.(*record.FakeRecorder)
+	for {
+		select {
+		case event := <-fakeRecorder.Events:
+			fields := strings.SplitN(event, " ", 3)
+			events = append(events, fields[0]+" "+fields[1])
+		default:
+			return events
+		}
+	}
+}
+
+// AssertEvents fail if the events recorded by a fake recorder are not the expected ones
+func AssertEvents(t *testing.T, recorder record.EventRecorder, expected ...string) {
+	t.Helper()
+	if events := RecordedEvents(recorder); strings.Join(events, ",") != strings.Join(expected, ",") {
+		t.Errorf("events are %v, expected %v", events, expected)
+	}
+}
+
+// StatusSubresourceClient emulate the status subresource of the API server: Update ignores the status
+// of an object, and replaces it with the stored one
+type StatusSubresourceClient struct {
+	client.Client
+}
+
+// Update implements client.Client
+func (c StatusSubresourceClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	status := reflect.ValueOf(obj).Elem().FieldByName("Status")
+	if !status.IsValid() {
+		return c.Client.Update(ctx, obj, opts...)
+	}
+	key, err := client.ObjectKeyFromObject(obj)
+	if err != nil {
+		return err
+	}
+	stored := obj.DeepCopyObject()
+	if err := c.Client.Get(ctx, key, stored); err != nil {
+		return err
+	}
+	status.Set(reflect.ValueOf(stored).Elem().FieldByName("Status"))
+	return c.Client.Update(ctx, obj, opts...)
+}
@@ -0,0 +1,154 @@
+package miniobucket
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/minio/minio-go"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/minioclient"
+)
+
+// purgeBatchSize is the maximum number of objects removed by each reconciliation of a bucket purge
+const purgeBatchSize = 1000
+
+// deleteBucket remove a bucket according to the MinioBucket deletion policy.
+// It return true once the finalizer can be removed, otherwise the result to requeue the MinioBucket.
+func (r *ReconcileMinioBucket) deleteBucket(ctx context.Context, reqLogger logr.Logger, instance *miniov1alpha1.MinioBucket, minioClient minioclient.BucketAdmin) (bool, reconcile.Result, error) {
+	switch instance.Spec.DeletionPolicy {
+	case miniov1alpha1.BucketDeletionRetain:
+		reqLogger.Info("Deletion policy is Retain, keep Minio bucket")
+		return true, reconcile.Result{}, nil
+	case miniov1alpha1.BucketDeletionPurge:
+		isEmpty, err := r.purgeBucket(ctx, reqLogger, instance, minioClient)
+		if err != nil {
+			return false, reconcile.Result{}, fmt.Errorf("r.purgeBucket: %w", err)
+		}
+		if !isEmpty {
+			purge := instance.Status.Purge
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionReady, corev1.ConditionFalse, "Purging", fmt.Sprintf(
+				"Removed %d objects, %d versions and %d incomplete uploads",
+				purge.RemovedObjects, purge.RemovedVersions, purge.AbortedUploads,
+			))
+			return false, reconcile.Result{Requeue: true}, nil
+		}
+	}
+
+	reqLogger.Info("Remove Minio bucket")
+	if err := minioClient.RemoveBucket(instance.Spec.Name); err != nil {
+		if minio.ToErrorResponse(err).Code == "BucketNotEmpty" {
+			reqLogger.Info("Minio bucket is not empty, wait for it to be empty")
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionReady, corev1.ConditionFalse, "BucketNotEmpty",
+				"Bucket is not empty, remove its objects or set deletionPolicy to Purge or Retain")
+			return false, reconcile.Result{RequeueAfter: bucketNotEmptyRequeueDelay}, nil
+		}
+		return false, reconcile.Result{}, fmt.Errorf("minioClient.RemoveBucket: %w", err)
+	}
+	reqLogger.Info("Minio bucket removed")
+	return true, reconcile.Result{}, nil
+}
+
+// purgeBucket remove a batch of incomplete uploads, objects and versions of a bucket,
+// recording progress in the MinioBucket status. It return true if the bucket was already empty.
+func (r *ReconcileMinioBucket) purgeBucket(ctx context.Context, reqLogger logr.Logger, instance *miniov1alpha1.MinioBucket, minioClient minioclient.BucketAdmin) (bool, error) {
+	if instance.Status.Purge == nil {
+		instance.Status.Purge = &miniov1alpha1.BucketPurgeStatus{}
+	}
+	purge := instance.Status.Purge
+	bucket := instance.Spec.Name
+
+	uploads, err := listIncompleteUploads(minioClient, bucket)
+	if err != nil {
+		return false, fmt.Errorf("listIncompleteUploads: %w", err)
+	}
+	for _, key := range uploads {
+		if err = minioClient.RemoveIncompleteUpload(bucket, key); err != nil {
+			return false, fmt.Errorf("minioClient.RemoveIncompleteUpload: %w", err)
+		}
+		purge.AbortedUploads++
+	}
+
+	objects, err := listObjects(minioClient, bucket)
+	if err != nil {
+		return false, fmt.Errorf("listObjects: %w", err)
+	}
+	if len(objects) > 0 {
+		objectsCh := make(chan string, len(objects))
+		for _, object := range objects {
+			objectsCh <- object
+		}
+		close(objectsCh)
+		removed := int64(len(objects))
+		for removeErr := range minioClient.RemoveObjects(bucket, objectsCh) {
+			if err == nil {
+				err = fmt.Errorf("minioClient.RemoveObjects: %s: %w", removeErr.ObjectName, removeErr.Err)
+			}
+			removed--
+		}
+		purge.RemovedObjects += removed
+		if err != nil {
+			return false, err
+		}
+	}
+
+	// Versions only exist on buckets which had versioning enabled
+	versions := []minioclient.ObjectVersion{}
+	if instance.Status.Versioning != "" {
+		versions, err = minioClient.ListObjectVersions(ctx, bucket, purgeBatchSize)
+		if err != nil {
+			return false, fmt.Errorf("minioClient.ListObjectVersions: %w", err)
+		}
+		for _, version := range versions {
+			if err = minioClient.RemoveObjectVersion(ctx, bucket, version); err != nil {
+				return false, fmt.Errorf("minioClient.RemoveObjectVersion: %w", err)
+			}
+			purge.RemovedVersions++
+		}
+	}
+
+	reqLogger.Info("Purged Minio bucket", "uploads", len(uploads), "objects", len(objects), "versions", len(versions))
+	return len(uploads) == 0 && len(objects) == 0 && len(versions) == 0, nil
+}
+
+// listIncompleteUploads return up to purgeBatchSize objects names with incomplete multipart uploads
+func listIncompleteUploads(minioClient minioclient.BucketAdmin, bucket string) ([]string, error) {
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	keys := []string{}
+	for upload := range minioClient.ListIncompleteUploads(bucket, "", true, doneCh) {
+		if upload.Err != nil {
+			return nil, fmt.Errorf("minioClient.ListIncompleteUploads: %w", upload.Err)
+		}
+		if len(keys) > 0 && keys[len(keys)-1] == upload.Key {
+			continue
+		}
+		keys = append(keys, upload.Key)
+		if len(keys) == purgeBatchSize {
+			break
+		}
+	}
+	return keys, nil
+}
+
+// listObjects return up to purgeBatchSize objects names
+func listObjects(minioClient minioclient.BucketAdmin, bucket string) ([]string, error) {
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	objects := []string{}
+	for object := range minioClient.ListObjectsV2(bucket, "", true, doneCh) {
+		if object.Err != nil {
+			return nil, fmt.Errorf("minioClient.ListObjectsV2: %w", object.Err)
+		}
+		objects = append(objects, object.Key)
+		if len(objects) == purgeBatchSize {
+			break
+		}
+	}
+	return objects, nil
+}
@@ -0,0 +1,51 @@
+package miniobucket
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/lifecycle"
+	"github.com/robotinfra/minio-resources-operator/pkg/minioclient"
+)
+
+// reconcileLifecycle converge the lifecycle configuration of a bucket to the MinioBucket spec
+func (r *ReconcileMinioBucket) reconcileLifecycle(reqLogger logr.Logger, instance *miniov1alpha1.MinioBucket, minioClient minioclient.BucketAdmin) error {
+	if instance.Spec.Lifecycle == nil {
+		instance.Status.Conditions.RemoveCondition(miniov1alpha1.ConditionLifecycleApplied)
+		return nil
+	}
+
+	lifecycleConfig, err := lifecycle.Render(instance.Spec.Lifecycle.Rules)
+	if err != nil {
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionLifecycleApplied, corev1.ConditionFalse, "InvalidLifecycle", err.Error())
+		return fmt.Errorf("lifecycle.Render: %w", err)
+	}
+
+	reqLogger.Info("Get bucket lifecycle")
+	existingLifecycle, err := minioClient.GetBucketLifecycle(instance.Spec.Name)
+	if err != nil {
+		return fmt.Errorf("minioClient.GetBucketLifecycle: %w", err)
+	}
+	reqLogger.Info("Got bucket lifecycle")
+
+	isEqual, err := lifecycle.Equal(existingLifecycle, lifecycleConfig)
+	if err != nil {
+		return fmt.Errorf("lifecycle.Equal: %w", err)
+	}
+	if isEqual {
+		reqLogger.Info("Bucket lifecycle is already correct")
+	} else {
+		reqLogger.Info("Bucket lifecycle is different, replace")
+		if err = minioClient.SetBucketLifecycle(instance.Spec.Name, lifecycleConfig); err != nil {
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionLifecycleApplied, corev1.ConditionFalse, "SetLifecycleFailed", err.Error())
+			return fmt.Errorf("minioClient.SetBucketLifecycle: %w", err)
+		}
+		reqLogger.Info("Bucket lifecycle changed")
+		recordDrift(instance, "lifecycle")
+	}
+	instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionLifecycleApplied, corev1.ConditionTrue, "LifecycleApplied", "")
+	return nil
+}
@@ -3,10 +3,16 @@ package miniobucket
 import (
 	"context"
 	"fmt"
+	"time"
 
-	"github.com/minio/minio-go"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -17,22 +23,43 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/controller/minioserver"
+	"github.com/robotinfra/minio-resources-operator/pkg/metrics"
+	"github.com/robotinfra/minio-resources-operator/pkg/minioclient"
+	"github.com/robotinfra/minio-resources-operator/pkg/policy"
 	"github.com/robotinfra/minio-resources-operator/pkg/utils"
 )
 
 var log = logf.Log.WithName("controller_miniobucket")
 
-const minioBucketFinalizer = "finalizer.bucket.minio.robotinfra.com"
+const (
+	minioBucketFinalizer = "finalizer.bucket.minio.robotinfra.com"
+
+	// serverOfflineRequeueDelay is the delay before retrying when the MinioServer is offline
+	serverOfflineRequeueDelay = time.Minute
+
+	// bucketNotEmptyRequeueDelay is the delay before retrying to delete a bucket which is not empty
+	bucketNotEmptyRequeueDelay = time.Minute
+
+	// serverIndexField is the field indexing MinioBucket by MinioServer name
+	serverIndexField = "spec.server"
+)
 
 // Add creates a new MinioBucket Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+func Add(mgr manager.Manager, minioClients minioclient.Clients) error {
+	return add(mgr, newReconciler(mgr, minioClients))
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileMinioBucket{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+func newReconciler(mgr manager.Manager, minioClients minioclient.Clients) reconcile.Reconciler {
+	return &ReconcileMinioBucket{
+		client:       mgr.GetClient(),
+		scheme:       mgr.GetScheme(),
+		recorder:     mgr.GetEventRecorderFor("miniobucket-controller"),
+		minioClients: minioClients,
+		usage:        newUsageCache(),
+	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -43,15 +70,72 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return fmt.Errorf("controller.New: %w", err)
 	}
 
+	// Index MinioBucket by MinioServer, to find them when a MinioServer changes
+	err = mgr.GetFieldIndexer().IndexField(&miniov1alpha1.MinioBucket{}, serverIndexField, func(o runtime.Object) []string {
+		return []string{o.(*miniov1alpha1.MinioBucket).Spec.Server}
+	})
+	if err != nil {
+		return fmt.Errorf("mgr.GetFieldIndexer().IndexField: %w", err)
+	}
+
 	// Watch for changes to primary resource MinioBucket
 	err = c.Watch(&source.Kind{Type: &miniov1alpha1.MinioBucket{}}, &handler.EnqueueRequestForObject{})
 	if err != nil {
 		return fmt.Errorf("c.Watch: %w", err)
 	}
 
+	// Watch for changes to MinioServer and requeue all its MinioBucket
+	err = c.Watch(&source.Kind{Type: &miniov1alpha1.MinioServer{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			return serversToRequests(mgr.GetClient(), []string{a.Meta.GetName()})
+		}),
+	}, minioserver.DependentsPredicate)
+	if err != nil {
+		return fmt.Errorf("c.Watch: %w", err)
+	}
+
+	// Watch for changes to MinioServer credentials Secrets
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: secretToRequests(mgr.GetClient()),
+	})
+	if err != nil {
+		return fmt.Errorf("c.Watch: %w", err)
+	}
+
 	return nil
 }
 
+// secretToRequests map a Secret to all MinioBucket of the MinioServer using it as credentials
+func secretToRequests(c client.Client) handler.ToRequestsFunc {
+	return func(a handler.MapObject) []reconcile.Request {
+		servers, err := minioclient.ServersUsingSecret(context.TODO(), c, a.Meta.GetNamespace(), a.Meta.GetName())
+		if err != nil {
+			log.Error(err, "minioclient.ServersUsingSecret")
+			return nil
+		}
+		return serversToRequests(c, servers)
+	}
+}
+
+// serversToRequests return a request for all MinioBucket of a list of MinioServer
+func serversToRequests(c client.Client, servers []string) []reconcile.Request {
+	requests := []reconcile.Request{}
+	for _, server := range servers {
+		buckets := &miniov1alpha1.MinioBucketList{}
+		if err := c.List(context.TODO(), buckets, client.MatchingField(serverIndexField, server)); err != nil {
+			log.Error(err, "c.List")
+			continue
+		}
+		for _, item := range buckets.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: item.Namespace,
+				Name:      item.Name,
+			}})
+		}
+	}
+	return requests
+}
+
 // blank assignment to verify that ReconcileMinioBucket implements reconcile.Reconciler
 var _ reconcile.Reconciler = &ReconcileMinioBucket{}
 
@@ -59,8 +143,11 @@ var _ reconcile.Reconciler = &ReconcileMinioBucket{}
 type ReconcileMinioBucket struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
-	client client.Client
-	scheme *runtime.Scheme
+	client       client.Client
+	scheme       *runtime.Scheme
+	recorder     record.EventRecorder
+	minioClients minioclient.Clients
+	usage        *usageCache
 }
 
 // Reconcile reads that state of the cluster for a MinioBucket object and makes changes based on the state read
@@ -85,40 +172,94 @@ func (r *ReconcileMinioBucket) Reconcile(request reconcile.Request) (reconcile.R
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, fmt.Errorf("r.client.Get: %w", err)
 	}
+	originalStatus := instance.Status.DeepCopy()
+
+	result, err := r.reconcileBucket(reqLogger, instance)
+	if err != nil {
+		// A failure retried with the same error is only reported once
+		if !originalStatus.Conditions.IsReconcileFailedWith(err) {
+			r.recorder.Event(instance, corev1.EventTypeWarning, "ReconcileFailed", err.Error())
+		}
+		instance.Status.Conditions.SetReconcileFailed(err)
+	}
+
+	if instance.GetDeletionTimestamp() != nil && !utils.Contains(instance.GetFinalizers(), minioBucketFinalizer) {
+		// Object is being deleted, there is no status to update
+		return result, err
+	}
+
+	if !equality.Semantic.DeepEqual(originalStatus, &instance.Status) {
+		reqLogger.Info("Update status")
+		if updateErr := r.client.Status().Update(context.TODO(), instance); updateErr != nil && err == nil {
+			return reconcile.Result{}, fmt.Errorf("r.client.Status().Update: %w", updateErr)
+		}
+	}
 
+	return result, err
+}
+
+// reconcileBucket converge the Minio bucket to the MinioBucket spec, and record observations in its status
+func (r *ReconcileMinioBucket) reconcileBucket(reqLogger logr.Logger, instance *miniov1alpha1.MinioBucket) (reconcile.Result, error) {
 	minioServer := &miniov1alpha1.MinioServer{}
 	if err := r.client.Get(context.TODO(), client.ObjectKey{
-		Name:      instance.Spec.Server,
+		Name: instance.Spec.Server,
 	}, minioServer); err != nil {
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionServerReachable, corev1.ConditionFalse, "ServerNotFound", err.Error())
 		return reconcile.Result{}, fmt.Errorf("r.client.Get: %w", err)
 	}
 
-	// doc is https://github.com/minio/minio/tree/master/pkg/madmin
-	minioClient, err := minio.New(minioServer.Spec.GetHostname(), minioServer.Spec.AccessKey, minioServer.Spec.SecretKey, minioServer.Spec.SSL)
+	if minioServer.IsOffline() {
+		reqLogger.Info("Minio server is offline, wait for it to be online")
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionServerReachable, corev1.ConditionFalse, "ServerOffline", minioServer.Status.LastError)
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionReady, corev1.ConditionFalse, "ServerOffline", fmt.Sprintf("MinioServer %s is offline", minioServer.Name))
+		return reconcile.Result{RequeueAfter: serverOfflineRequeueDelay}, nil
+	}
+
+	minioClient, err := r.minioClients.BucketAdmin(context.TODO(), minioServer)
 	if err != nil {
-		return reconcile.Result{}, fmt.Errorf("minio.New: %w", err)
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionServerReachable, corev1.ConditionFalse, "ClientFailed", err.Error())
+		return reconcile.Result{}, fmt.Errorf("r.minioClients.BucketAdmin: %w", err)
 	}
 
 	reqLogger.Info("Check if Minio bucket exists")
 	bucketExist, err := minioClient.BucketExists(instance.Spec.Name)
 	if err != nil {
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionServerReachable, corev1.ConditionFalse, "RequestFailed", err.Error())
 		return reconcile.Result{}, fmt.Errorf("minioClient.BucketExists: %w", err)
 	}
+	instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionServerReachable, corev1.ConditionTrue, "Reachable", "")
 	reqLogger.Info("Got bucket info")
 
 	finalizerPresent := utils.Contains(instance.GetFinalizers(), minioBucketFinalizer)
 
+	// An existing bucket is only managed once owned, the finalizer is added after this check
+	if instance.GetDeletionTimestamp() == nil && bucketExist && !finalizerPresent {
+		conflict, err := r.checkOwnership(context.TODO(), reqLogger, instance, minioClient)
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("r.checkOwnership: %w", err)
+		}
+		if conflict != "" {
+			reqLogger.Info("Bucket is not owned by the MinioBucket", "reason", conflict)
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionConflict, corev1.ConditionTrue, "NotOwned", conflict)
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionReady, corev1.ConditionFalse, "Conflict", conflict)
+			return reconcile.Result{}, nil
+		}
+	}
+
 	if instance.GetDeletionTimestamp() != nil {
 		if finalizerPresent {
 			// Run finalization logic for. If the
 			// finalization logic fails, don't remove the finalizer so
 			// that we can retry during the next reconciliation.
 			if bucketExist {
-				reqLogger.Info("Instance marked for deletion, remove Minio bucket")
-				if err = minioClient.RemoveBucket(instance.Spec.Name); err != nil {
-					return reconcile.Result{}, fmt.Errorf("minioClient.RemoveBucket: %w", err)
+				reqLogger.Info("Instance marked for deletion, delete Minio bucket", "deletionPolicy", instance.Spec.DeletionPolicy)
+				isDeleted, result, err := r.deleteBucket(context.TODO(), reqLogger, instance, minioClient)
+				if err != nil {
+					return reconcile.Result{}, fmt.Errorf("r.deleteBucket: %w", err)
+				}
+				if !isDeleted {
+					return result, nil
 				}
-				reqLogger.Info("Minio bucket removed")
 			} else {
 				reqLogger.Info("Minio bucket already removed")
 			}
@@ -131,6 +272,7 @@ func (r *ReconcileMinioBucket) Reconcile(request reconcile.Request) (reconcile.R
 				return reconcile.Result{}, fmt.Errorf("r.client.Update: %w", err)
 			}
 			reqLogger.Info("Finalizer deleted")
+			metrics.DeleteBucketUsage(instance.Namespace, instance.Name, instance.Spec.Server, instance.Spec.Name)
 		} else {
 			reqLogger.Info("Instance marked for deletion, but not minioBucketFinalizer")
 		}
@@ -143,11 +285,21 @@ func (r *ReconcileMinioBucket) Reconcile(request reconcile.Request) (reconcile.R
 
 	if !finalizerPresent {
 		reqLogger.Info("No finalizer, add it")
+		// Update replaces instance with the stored object, keep the status observed by this reconcile
+		status := instance.Status.DeepCopy()
 		instance.SetFinalizers(append(instance.GetFinalizers(), minioBucketFinalizer))
 		if err = r.client.Update(context.TODO(), instance); err != nil {
 			return reconcile.Result{}, fmt.Errorf("r.client.Update: %w", err)
 		}
+		instance.Status = *status
 		reqLogger.Info("Finalizer added")
+		r.recorder.Eventf(instance, corev1.EventTypeNormal, "FinalizerAdded", "Finalizer %s added", minioBucketFinalizer)
+	}
+
+	specPolicy, err := desiredPolicy(instance)
+	if err != nil {
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionPolicyApplied, corev1.ConditionFalse, "InvalidPolicy", err.Error())
+		return reconcile.Result{}, fmt.Errorf("desiredPolicy: %w", err)
 	}
 
 	if bucketExist {
@@ -158,12 +310,21 @@ func (r *ReconcileMinioBucket) Reconcile(request reconcile.Request) (reconcile.R
 		}
 		reqLogger.Info("Got bucket policy")
 
-		if bucketPolicy != instance.Spec.Policy {
-			reqLogger.Info("Bucket policy is different, replace", "Spec.Name", instance.Spec.Name)
-			if err = minioClient.SetBucketPolicy(instance.Spec.Name, instance.Spec.Policy); err != nil {
+		isPolicyEqual, err := policy.Equal(bucketPolicy, specPolicy)
+		if err != nil {
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionPolicyApplied, corev1.ConditionFalse, "InvalidPolicy", err.Error())
+			return reconcile.Result{}, fmt.Errorf("policy.Equal: %w", err)
+		}
+		if !isPolicyEqual {
+			diff, _ := policy.Diff(bucketPolicy, specPolicy)
+			reqLogger.Info("Bucket policy is different, replace", "Spec.Name", instance.Spec.Name, "diff", diff)
+			if err = minioClient.SetBucketPolicy(instance.Spec.Name, specPolicy); err != nil {
+				instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionPolicyApplied, corev1.ConditionFalse, "SetPolicyFailed", err.Error())
 				return reconcile.Result{}, fmt.Errorf("minioClient.SetBucketPolicy: %w", err)
 			}
 			reqLogger.Info("Bucket policy changed")
+			recordDrift(instance, "policy")
+			r.recorder.Eventf(instance, corev1.EventTypeNormal, "PolicyUpdated", "Policy of bucket %s updated", instance.Spec.Name)
 		} else {
 			reqLogger.Info("Bucket policy is already correct")
 		}
@@ -173,12 +334,65 @@ func (r *ReconcileMinioBucket) Reconcile(request reconcile.Request) (reconcile.R
 			return reconcile.Result{}, fmt.Errorf("minioClient.MakeBucket: %w", err)
 		}
 		reqLogger.Info("Bucket created, set policy", "Spec.Name", instance.Spec.Name)
-		if err = minioClient.SetBucketPolicy(instance.Spec.Name, instance.Spec.Policy); err != nil {
+		r.recorder.Eventf(instance, corev1.EventTypeNormal, "BucketCreated", "Bucket %s created", instance.Spec.Name)
+		if err = minioClient.SetBucketPolicy(instance.Spec.Name, specPolicy); err != nil {
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionPolicyApplied, corev1.ConditionFalse, "SetPolicyFailed", err.Error())
 			return reconcile.Result{}, fmt.Errorf("minioClient.SetBucketPolicy: %w", err)
 		}
 		reqLogger.Info("Bucket policy set")
 	}
 
+	if err = r.tagOwner(context.TODO(), reqLogger, instance, minioClient); err != nil {
+		return reconcile.Result{}, fmt.Errorf("r.tagOwner: %w", err)
+	}
+	instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionConflict, corev1.ConditionFalse, "Owned", "")
+	instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionPolicyApplied, corev1.ConditionTrue, "PolicyApplied", "")
+	instance.Status.PolicyHash = utils.Hash(specPolicy)
+
+	if instance.Status.CreationTime == nil {
+		reqLogger.Info("Get bucket creation time")
+		buckets, err := minioClient.ListBuckets()
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("minioClient.ListBuckets: %w", err)
+		}
+		for _, bucket := range buckets {
+			if bucket.Name == instance.Spec.Name {
+				creationTime := metav1.NewTime(bucket.CreationDate)
+				instance.Status.CreationTime = &creationTime
+			}
+		}
+	}
+
+	if err = r.reconcileVersioning(context.TODO(), reqLogger, instance, minioClient); err != nil {
+		return reconcile.Result{}, fmt.Errorf("r.reconcileVersioning: %w", err)
+	}
+
+	if err = r.reconcileLifecycle(reqLogger, instance, minioClient); err != nil {
+		return reconcile.Result{}, fmt.Errorf("r.reconcileLifecycle: %w", err)
+	}
+
+	if err = r.reconcileNotifications(reqLogger, instance, minioClient); err != nil {
+		return reconcile.Result{}, fmt.Errorf("r.reconcileNotifications: %w", err)
+	}
+
+	// The MinioBucket is requeued to collect its usage periodically
+	result := reconcile.Result{RequeueAfter: r.reconcileUsage(context.TODO(), reqLogger, instance, minioServer)}
+
+	instance.Status.ObservedGeneration = instance.GetGeneration()
+	if condition := instance.Status.Conditions.GetCondition(miniov1alpha1.ConditionVersioningApplied); condition != nil && condition.Status != corev1.ConditionTrue {
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionReady, corev1.ConditionFalse, "VersioningNotSupported", condition.Message)
+		reqLogger.Info("MinioBucket reconcilied, without versioning")
+		return result, nil
+	}
+	instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionReady, corev1.ConditionTrue, "Reconciled", "")
 	reqLogger.Info("MinioBucket reconcilied")
-	return reconcile.Result{}, nil
+	return result, nil
+}
+
+// recordDrift record the correction of a bucket field, when changed outside of the operator
+// after the MinioBucket spec was applied
+func recordDrift(instance *miniov1alpha1.MinioBucket, field string) {
+	if instance.Status.ObservedGeneration == instance.GetGeneration() {
+		metrics.DriftCorrected(instance.Spec.Server, "MinioBucket", field)
+	}
 }
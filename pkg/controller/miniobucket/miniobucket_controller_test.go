@@ -0,0 +1,457 @@
+package miniobucket
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/controller/internal/controllertest"
+	miniofake "github.com/robotinfra/minio-resources-operator/pkg/minioclient/fake"
+	"github.com/robotinfra/minio-resources-operator/pkg/utils"
+)
+
+const (
+	testPolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::mybucket/*"]}]}`
+	// testPolicyFormatted is testPolicy with another formatting, and single values instead of lists
+	testPolicyFormatted = `{
+  "Version": "2012-10-17",
+  "Statement": {
+    "Resource": "arn:aws:s3:::mybucket/*",
+    "Action": "s3:GetObject",
+    "Principal": "*",
+    "Effect": "Allow"
+  }
+}`
+	otherPolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:ListBucket"],"Resource":["arn:aws:s3:::mybucket"]}]}`
+)
+
+var request = reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "bucket"}}
+
+// newTestReconciler return a reconciler using a fake Kubernetes client with objects, and a fake Minio server
+func newTestReconciler(t *testing.T, objs ...runtime.Object) (*ReconcileMinioBucket, *miniofake.Server) {
+	c, s := controllertest.NewClient(t, objs...)
+	server := miniofake.NewServer()
+	return &ReconcileMinioBucket{
+		client:       c,
+		scheme:       s,
+		recorder:     record.NewFakeRecorder(100),
+		minioClients: server,
+		usage:        newUsageCache(),
+	}, server
+}
+
+func newMinioBucket() *miniov1alpha1.MinioBucket {
+	return &miniov1alpha1.MinioBucket{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "bucket", UID: "bucket-uid"},
+		Spec: miniov1alpha1.MinioBucketSpec{
+			Server: "test",
+			Name:   "mybucket",
+			Policy: testPolicy,
+		},
+	}
+}
+
+// getMinioBucket return the MinioBucket of the request
+func getMinioBucket(t *testing.T, r *ReconcileMinioBucket) *miniov1alpha1.MinioBucket {
+	t.Helper()
+	instance := &miniov1alpha1.MinioBucket{}
+	controllertest.Get(t, r.client, request, instance)
+	return instance
+}
+
+func TestReconcileCreate(t *testing.T) {
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), newMinioBucket())
+
+	controllertest.ReconcileOK(t, r, request)
+
+	bucket, ok := server.GetBucket("mybucket")
+	if !ok {
+		t.Fatal("bucket not created")
+	}
+	if bucket.Policy != testPolicy {
+		t.Errorf("bucket policy is %q, expected %q", bucket.Policy, testPolicy)
+	}
+	if owner := bucket.Tags[miniov1alpha1.OwnerTag]; owner != "default/bucket" {
+		t.Errorf("bucket owner tag is %q, expected default/bucket", owner)
+	}
+
+	instance := getMinioBucket(t, r)
+	controllertest.AssertFinalizer(t, instance, minioBucketFinalizer, true)
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionReady, corev1.ConditionTrue, "Reconciled")
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionPolicyApplied, corev1.ConditionTrue, "PolicyApplied")
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionConflict, corev1.ConditionFalse, "Owned")
+	if instance.Status.PolicyHash != utils.Hash(testPolicy) {
+		t.Errorf("policy hash is %q, expected %q", instance.Status.PolicyHash, utils.Hash(testPolicy))
+	}
+	if instance.Status.CreationTime == nil || !instance.Status.CreationTime.Time.Equal(bucket.CreationDate) {
+		t.Errorf("creation time is %v, expected %v", instance.Status.CreationTime, bucket.CreationDate)
+	}
+}
+
+func TestReconcileCreateKeepsStatus(t *testing.T) {
+	r, _ := newTestReconciler(t, controllertest.NewMinioServer(), newMinioBucket())
+	r.client = controllertest.StatusSubresourceClient{Client: r.client}
+
+	controllertest.ReconcileOK(t, r, request)
+
+	// Conditions set before the finalizer is added are not lost
+	instance := getMinioBucket(t, r)
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionServerReachable, corev1.ConditionTrue, "Reachable")
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionReady, corev1.ConditionTrue, "Reconciled")
+}
+
+func TestReconcileUpdate(t *testing.T) {
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), newMinioBucket())
+	controllertest.ReconcileOK(t, r, request)
+
+	instance := getMinioBucket(t, r)
+	instance.Spec.Policy = otherPolicy
+	controllertest.Update(t, r.client, instance)
+	server.ResetCalls()
+	controllertest.ReconcileOK(t, r, request)
+
+	controllertest.AssertCallCount(t, server, "SetBucketPolicy", 1)
+	controllertest.AssertCallCount(t, server, "MakeBucket", 0)
+	if bucket, _ := server.GetBucket("mybucket"); bucket.Policy != otherPolicy {
+		t.Errorf("bucket policy is %q, expected %q", bucket.Policy, otherPolicy)
+	}
+	if instance = getMinioBucket(t, r); instance.Status.PolicyHash != utils.Hash(otherPolicy) {
+		t.Errorf("policy hash not updated")
+	}
+}
+
+func TestReconcileSteadyState(t *testing.T) {
+	bucket := newMinioBucket()
+	bucket.Spec.Policy = testPolicyFormatted
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), bucket)
+	controllertest.ReconcileOK(t, r, request)
+
+	// The server returns policies in its own format
+	if err := server.SetBucketPolicy("mybucket", testPolicy); err != nil {
+		t.Fatalf("server.SetBucketPolicy: %v", err)
+	}
+	server.ResetCalls()
+	controllertest.ReconcileOK(t, r, request)
+
+	controllertest.AssertCallCount(t, server, "SetBucketPolicy", 0)
+	controllertest.AssertCallCount(t, server, "SetBucketTags", 0)
+}
+
+func TestReconcileDrift(t *testing.T) {
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), newMinioBucket())
+	controllertest.ReconcileOK(t, r, request)
+
+	if err := server.SetBucketPolicy("mybucket", otherPolicy); err != nil {
+		t.Fatalf("server.SetBucketPolicy: %v", err)
+	}
+	if err := server.SetBucketTags(context.TODO(), "mybucket", map[string]string{"team": "data"}); err != nil {
+		t.Fatalf("server.SetBucketTags: %v", err)
+	}
+	controllertest.ReconcileOK(t, r, request)
+
+	bucket, _ := server.GetBucket("mybucket")
+	if bucket.Policy != testPolicy {
+		t.Errorf("bucket policy is %q, expected %q", bucket.Policy, testPolicy)
+	}
+	if bucket.Tags["team"] != "data" || bucket.Tags[miniov1alpha1.OwnerTag] != "default/bucket" {
+		t.Errorf("bucket tags are %v, expected team and owner tags", bucket.Tags)
+	}
+}
+
+func TestReconcileConflict(t *testing.T) {
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), newMinioBucket())
+	if err := server.MakeBucket("mybucket", ""); err != nil {
+		t.Fatalf("server.MakeBucket: %v", err)
+	}
+	server.ResetCalls()
+
+	controllertest.ReconcileOK(t, r, request)
+
+	controllertest.AssertCallCount(t, server, "SetBucketPolicy", 0)
+	instance := getMinioBucket(t, r)
+	controllertest.AssertFinalizer(t, instance, minioBucketFinalizer, false)
+	controllertest.AssertConflict(t, instance.Status.Conditions, "NotOwned")
+
+	instance.Spec.Adopt = true
+	controllertest.Update(t, r.client, instance)
+	controllertest.ReconcileOK(t, r, request)
+
+	instance = getMinioBucket(t, r)
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionConflict, corev1.ConditionFalse, "Owned")
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionReady, corev1.ConditionTrue, "Reconciled")
+	if bucket, _ := server.GetBucket("mybucket"); bucket.Policy != testPolicy {
+		t.Errorf("adopted bucket policy is %q, expected %q", bucket.Policy, testPolicy)
+	}
+}
+
+func TestReconcileErrors(t *testing.T) {
+	tests := []struct {
+		method string
+		reason string
+	}{
+		{method: "BucketAdmin", reason: "ClientFailed"},
+		{method: "BucketExists", reason: "RequestFailed"},
+		{method: "MakeBucket", reason: ""},
+		{method: "SetBucketPolicy", reason: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			r, server := newTestReconciler(t, controllertest.NewMinioServer(), newMinioBucket())
+			injected := errors.New("injected error")
+			server.SetError(tt.method, injected)
+
+			if _, err := r.Reconcile(request); !errors.Is(err, injected) {
+				t.Fatalf("r.Reconcile returned %v, expected the injected error", err)
+			}
+			instance := getMinioBucket(t, r)
+			controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionReady, corev1.ConditionFalse, "ReconcileFailed")
+			if tt.reason != "" {
+				controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionServerReachable, corev1.ConditionFalse, tt.reason)
+			}
+
+			server.SetError(tt.method, nil)
+			controllertest.ReconcileOK(t, r, request)
+			controllertest.AssertCondition(t, getMinioBucket(t, r).Status.Conditions, miniov1alpha1.ConditionReady, corev1.ConditionTrue, "Reconciled")
+		})
+	}
+}
+
+func TestReconcileServerOffline(t *testing.T) {
+	minioServer := controllertest.NewMinioServer()
+	now := metav1.Now()
+	minioServer.Status.LastProbeTime = &now
+	minioServer.Status.Online = false
+	r, server := newTestReconciler(t, minioServer, newMinioBucket())
+
+	result := controllertest.ReconcileOK(t, r, request)
+
+	if result.RequeueAfter != serverOfflineRequeueDelay {
+		t.Errorf("requeue after %v, expected %v", result.RequeueAfter, serverOfflineRequeueDelay)
+	}
+	if calls := server.Calls(); len(calls) != 0 {
+		t.Errorf("calls %v to an offline server", calls)
+	}
+	controllertest.AssertCondition(t, getMinioBucket(t, r).Status.Conditions, miniov1alpha1.ConditionReady, corev1.ConditionFalse, "ServerOffline")
+}
+
+// deletedMinioBucket return a MinioBucket being deleted, with its finalizer
+func deletedMinioBucket(deletionPolicy miniov1alpha1.BucketDeletionPolicy) *miniov1alpha1.MinioBucket {
+	bucket := newMinioBucket()
+	now := metav1.Now()
+	bucket.SetDeletionTimestamp(&now)
+	bucket.SetFinalizers([]string{minioBucketFinalizer})
+	bucket.Spec.DeletionPolicy = deletionPolicy
+	return bucket
+}
+
+func TestReconcileDelete(t *testing.T) {
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), deletedMinioBucket(""))
+	if err := server.MakeBucket("mybucket", ""); err != nil {
+		t.Fatalf("server.MakeBucket: %v", err)
+	}
+
+	controllertest.ReconcileOK(t, r, request)
+
+	if _, ok := server.GetBucket("mybucket"); ok {
+		t.Error("bucket not removed")
+	}
+	instance := getMinioBucket(t, r)
+	controllertest.AssertFinalizer(t, instance, minioBucketFinalizer, false)
+}
+
+func TestReconcileDeleteNotEmpty(t *testing.T) {
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), deletedMinioBucket(miniov1alpha1.BucketDeletionDelete))
+	if err := server.MakeBucket("mybucket", ""); err != nil {
+		t.Fatalf("server.MakeBucket: %v", err)
+	}
+	if err := server.PutObjects("mybucket", "a", "b"); err != nil {
+		t.Fatalf("server.PutObjects: %v", err)
+	}
+
+	result := controllertest.ReconcileOK(t, r, request)
+
+	if result.RequeueAfter != bucketNotEmptyRequeueDelay {
+		t.Errorf("requeue after %v, expected %v", result.RequeueAfter, bucketNotEmptyRequeueDelay)
+	}
+	if _, ok := server.GetBucket("mybucket"); !ok {
+		t.Error("bucket not empty removed")
+	}
+	instance := getMinioBucket(t, r)
+	controllertest.AssertFinalizer(t, instance, minioBucketFinalizer, true)
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionReady, corev1.ConditionFalse, "BucketNotEmpty")
+}
+
+func TestReconcileDeleteRetain(t *testing.T) {
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), deletedMinioBucket(miniov1alpha1.BucketDeletionRetain))
+	if err := server.MakeBucket("mybucket", ""); err != nil {
+		t.Fatalf("server.MakeBucket: %v", err)
+	}
+	if err := server.PutObjects("mybucket", "a"); err != nil {
+		t.Fatalf("server.PutObjects: %v", err)
+	}
+
+	controllertest.ReconcileOK(t, r, request)
+
+	if bucket, ok := server.GetBucket("mybucket"); !ok || len(bucket.Objects) != 1 {
+		t.Error("retained bucket or its objects removed")
+	}
+	instance := getMinioBucket(t, r)
+	controllertest.AssertFinalizer(t, instance, minioBucketFinalizer, false)
+}
+
+func TestReconcileDeletePurge(t *testing.T) {
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), deletedMinioBucket(miniov1alpha1.BucketDeletionPurge))
+	if err := server.MakeBucket("mybucket", ""); err != nil {
+		t.Fatalf("server.MakeBucket: %v", err)
+	}
+	if err := server.PutObjects("mybucket", "a", "b", "c"); err != nil {
+		t.Fatalf("server.PutObjects: %v", err)
+	}
+
+	result := controllertest.ReconcileOK(t, r, request)
+
+	if !result.Requeue {
+		t.Error("purge not requeued")
+	}
+	instance := getMinioBucket(t, r)
+	if instance.Status.Purge == nil || instance.Status.Purge.RemovedObjects != 3 {
+		t.Errorf("purge status is %+v, expected 3 removed objects", instance.Status.Purge)
+	}
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionReady, corev1.ConditionFalse, "Purging")
+
+	controllertest.ReconcileOK(t, r, request)
+
+	if _, ok := server.GetBucket("mybucket"); ok {
+		t.Error("purged bucket not removed")
+	}
+	instance = getMinioBucket(t, r)
+	controllertest.AssertFinalizer(t, instance, minioBucketFinalizer, false)
+}
+
+func TestReconcileDeleteError(t *testing.T) {
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), deletedMinioBucket(""))
+	if err := server.MakeBucket("mybucket", ""); err != nil {
+		t.Fatalf("server.MakeBucket: %v", err)
+	}
+	injected := errors.New("injected error")
+	server.SetError("RemoveBucket", injected)
+
+	if _, err := r.Reconcile(request); !errors.Is(err, injected) {
+		t.Fatalf("r.Reconcile returned %v, expected the injected error", err)
+	}
+	instance := getMinioBucket(t, r)
+	controllertest.AssertFinalizer(t, instance, minioBucketFinalizer, true)
+}
+
+func TestReconcileEvents(t *testing.T) {
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), newMinioBucket())
+
+	controllertest.ReconcileOK(t, r, request)
+	controllertest.AssertEvents(t, r.recorder, "Normal FinalizerAdded", "Normal BucketCreated")
+
+	controllertest.ReconcileOK(t, r, request)
+	controllertest.AssertEvents(t, r.recorder)
+
+	instance := getMinioBucket(t, r)
+	instance.Spec.Policy = otherPolicy
+	controllertest.Update(t, r.client, instance)
+	controllertest.ReconcileOK(t, r, request)
+	controllertest.AssertEvents(t, r.recorder, "Normal PolicyUpdated")
+
+	server.SetError("BucketExists", errors.New("injected error"))
+	for i := 0; i < 3; i++ {
+		if _, err := r.Reconcile(request); err == nil {
+			t.Fatal("r.Reconcile succeeded with an injected error")
+		}
+	}
+	controllertest.AssertEvents(t, r.recorder, "Warning ReconcileFailed")
+
+	server.SetError("BucketExists", errors.New("another error"))
+	if _, err := r.Reconcile(request); err == nil {
+		t.Fatal("r.Reconcile succeeded with an injected error")
+	}
+	controllertest.AssertEvents(t, r.recorder, "Warning ReconcileFailed")
+}
+
+func TestReconcileUsage(t *testing.T) {
+	minioServer := controllertest.NewMinioServer()
+	minioServer.Spec.BucketUsageInterval = &metav1.Duration{Duration: time.Hour}
+	bucket := newMinioBucket()
+	bucket.Spec.Adopt = true
+	r, server := newTestReconciler(t, minioServer, bucket)
+	if err := server.MakeBucket("mybucket", ""); err != nil {
+		t.Fatalf("server.MakeBucket: %v", err)
+	}
+	if err := server.PutObjects("mybucket", "a", "b", "c"); err != nil {
+		t.Fatalf("server.PutObjects: %v", err)
+	}
+
+	// A bucket is not collected until the Minio server computes its usage
+	controllertest.ReconcileOK(t, r, request)
+	instance := getMinioBucket(t, r)
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionUsageCollected, corev1.ConditionFalse, "NotComputed")
+	if instance.Status.Usage != nil {
+		t.Errorf("usage is %+v, expected none before it is computed", instance.Status.Usage)
+	}
+
+	if err := server.SetBucketSize("mybucket", 1024); err != nil {
+		t.Fatalf("server.SetBucketSize: %v", err)
+	}
+	r.usage = newUsageCache()
+	result := controllertest.ReconcileOK(t, r, request)
+
+	if result.RequeueAfter != time.Hour {
+		t.Errorf("requeued after %v, expected %v", result.RequeueAfter, time.Hour)
+	}
+	instance = getMinioBucket(t, r)
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionUsageCollected, corev1.ConditionTrue, "Collected")
+	if usage := instance.Status.Usage; usage == nil || usage.Size != 1024 {
+		t.Fatalf("usage is %+v, expected 1024 bytes", usage)
+	}
+	// Objects are not listed, only the data usage computed by Minio is used
+	controllertest.AssertCallCount(t, server, "ListObjectsV2", 0)
+
+	// Usage is not collected again before the interval
+	server.ResetCalls()
+	if result = controllertest.ReconcileOK(t, r, request); result.RequeueAfter <= 0 || result.RequeueAfter > time.Hour {
+		t.Errorf("requeued after %v, expected less than %v", result.RequeueAfter, time.Hour)
+	}
+	controllertest.AssertCallCount(t, server, "DataUsageInfo", 0)
+
+	// Failures don't prevent the bucket to be ready
+	instance = getMinioBucket(t, r)
+	instance.Status.Usage.LastUpdateTime = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	if err := r.client.Status().Update(context.TODO(), instance); err != nil {
+		t.Fatalf("r.client.Status().Update: %v", err)
+	}
+	r.usage = newUsageCache()
+	server.SetError("DataUsageInfo", errors.New("injected error"))
+	controllertest.ReconcileOK(t, r, request)
+	instance = getMinioBucket(t, r)
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionUsageCollected, corev1.ConditionFalse, "CollectFailed")
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionReady, corev1.ConditionTrue, "Reconciled")
+
+	// Usage is removed when not collected anymore
+	minioServer = &miniov1alpha1.MinioServer{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: "test"}, minioServer); err != nil {
+		t.Fatalf("r.client.Get: %v", err)
+	}
+	minioServer.Spec.BucketUsageInterval = nil
+	controllertest.Update(t, r.client, minioServer)
+	if result = controllertest.ReconcileOK(t, r, request); result.RequeueAfter != 0 {
+		t.Errorf("requeued after %v without usage collection", result.RequeueAfter)
+	}
+	instance = getMinioBucket(t, r)
+	if instance.Status.Usage != nil || instance.Status.Conditions.GetCondition(miniov1alpha1.ConditionUsageCollected) != nil {
+		t.Errorf("usage %+v not removed", instance.Status.Usage)
+	}
+}
@@ -0,0 +1,142 @@
+package miniobucket
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/minio/minio-go"
+	corev1 "k8s.io/api/core/v1"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/minioclient"
+)
+
+// reconcileNotifications converge the event notifications of a bucket to the MinioBucket spec
+func (r *ReconcileMinioBucket) reconcileNotifications(reqLogger logr.Logger, instance *miniov1alpha1.MinioBucket, minioClient minioclient.BucketAdmin) error {
+	if instance.Spec.Notifications == nil {
+		instance.Status.Conditions.RemoveCondition(miniov1alpha1.ConditionNotificationsApplied)
+		return nil
+	}
+
+	notification, err := bucketNotification(instance.Spec.Notifications.Rules)
+	if err != nil {
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionNotificationsApplied, corev1.ConditionFalse, "InvalidNotifications", err.Error())
+		return fmt.Errorf("bucketNotification: %w", err)
+	}
+
+	reqLogger.Info("Get bucket notifications")
+	existingNotification, err := minioClient.GetBucketNotification(instance.Spec.Name)
+	if err != nil {
+		return fmt.Errorf("minioClient.GetBucketNotification: %w", err)
+	}
+	reqLogger.Info("Got bucket notifications")
+
+	if reflect.DeepEqual(notificationRules(existingNotification), notificationRules(notification)) {
+		reqLogger.Info("Bucket notifications are already correct")
+	} else if len(instance.Spec.Notifications.Rules) == 0 {
+		reqLogger.Info("Bucket notifications are not declared, remove them")
+		if err = minioClient.RemoveAllBucketNotification(instance.Spec.Name); err != nil {
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionNotificationsApplied, corev1.ConditionFalse, "SetNotificationsFailed", err.Error())
+			return fmt.Errorf("minioClient.RemoveAllBucketNotification: %w", err)
+		}
+		reqLogger.Info("Bucket notifications removed")
+		recordDrift(instance, "notifications")
+	} else {
+		reqLogger.Info("Bucket notifications are different, replace")
+		if err = minioClient.SetBucketNotification(instance.Spec.Name, notification); err != nil {
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionNotificationsApplied, corev1.ConditionFalse, "SetNotificationsFailed", err.Error())
+			return fmt.Errorf("minioClient.SetBucketNotification: %w", err)
+		}
+		reqLogger.Info("Bucket notifications changed")
+		recordDrift(instance, "notifications")
+	}
+	instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionNotificationsApplied, corev1.ConditionTrue, "NotificationsApplied", "")
+	return nil
+}
+
+// bucketNotification return the bucket notification configuration of notification rules
+func bucketNotification(rules []miniov1alpha1.NotificationRule) (minio.BucketNotification, error) {
+	notification := minio.BucketNotification{}
+	for _, rule := range rules {
+		arn, err := parseArn(rule.Arn)
+		if err != nil {
+			return notification, err
+		}
+
+		config := minio.NewNotificationConfig(arn)
+		for _, event := range rule.Events {
+			config.AddEvents(minio.NotificationEventType(event))
+		}
+		if rule.Prefix != "" {
+			config.AddFilterPrefix(rule.Prefix)
+		}
+		if rule.Suffix != "" {
+			config.AddFilterSuffix(rule.Suffix)
+		}
+
+		added := false
+		switch arn.Service {
+		case "sqs":
+			added = notification.AddQueue(config)
+		case "sns":
+			added = notification.AddTopic(config)
+		case "lambda":
+			added = notification.AddLambda(config)
+		default:
+			return notification, fmt.Errorf("unsupported notification target service %s in %s", arn.Service, rule.Arn)
+		}
+		if !added {
+			return notification, fmt.Errorf("notification rule of %s overlaps another rule", rule.Arn)
+		}
+	}
+	return notification, nil
+}
+
+// parseArn parse an ARN such as arn:minio:sqs::1:webhook
+func parseArn(s string) (minio.Arn, error) {
+	parts := strings.SplitN(s, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" {
+		return minio.Arn{}, fmt.Errorf("invalid ARN %s", s)
+	}
+	return minio.NewArn(parts[1], parts[2], parts[3], parts[4], parts[5]), nil
+}
+
+// notificationRules return the notification rules of a bucket notification configuration,
+// sorted to compare configurations regardless of order and ids given by the server
+func notificationRules(notification minio.BucketNotification) []miniov1alpha1.NotificationRule {
+	rules := []miniov1alpha1.NotificationRule{}
+	add := func(arn string, config minio.NotificationConfig) {
+		rule := miniov1alpha1.NotificationRule{Arn: arn, Events: []string{}}
+		for _, event := range config.Events {
+			rule.Events = append(rule.Events, string(event))
+		}
+		sort.Strings(rule.Events)
+		if config.Filter != nil {
+			for _, filterRule := range config.Filter.S3Key.FilterRules {
+				switch filterRule.Name {
+				case "prefix":
+					rule.Prefix = filterRule.Value
+				case "suffix":
+					rule.Suffix = filterRule.Value
+				}
+			}
+		}
+		rules = append(rules, rule)
+	}
+	for _, config := range notification.QueueConfigs {
+		add(config.Queue, config.NotificationConfig)
+	}
+	for _, config := range notification.TopicConfigs {
+		add(config.Topic, config.NotificationConfig)
+	}
+	for _, config := range notification.LambdaConfigs {
+		add(config.Lambda, config.NotificationConfig)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return fmt.Sprint(rules[i]) < fmt.Sprint(rules[j])
+	})
+	return rules
+}
@@ -0,0 +1,70 @@
+package miniobucket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/minioclient"
+	"github.com/robotinfra/minio-resources-operator/pkg/utils"
+)
+
+// checkOwnership return why a MinioBucket can't manage an existing bucket, empty if it can.
+// A bucket is owned by the MinioBucket recorded in its owner tag, or by the MinioBucket holding the finalizer.
+func (r *ReconcileMinioBucket) checkOwnership(ctx context.Context, reqLogger logr.Logger, instance *miniov1alpha1.MinioBucket, minioClient minioclient.BucketAdmin) (string, error) {
+	owner := ""
+	tags, err := minioClient.GetBucketTags(ctx, instance.Spec.Name)
+	switch {
+	case errors.Is(err, minioclient.ErrTaggingNotSupported):
+		reqLogger.Info("Bucket tagging is not supported by the server, owner is unknown")
+	case err != nil:
+		return "", fmt.Errorf("minioClient.GetBucketTags: %w", err)
+	default:
+		owner = tags[miniov1alpha1.OwnerTag]
+	}
+	if owner == instance.GetOwnerTag() {
+		return "", nil
+	}
+
+	buckets := &miniov1alpha1.MinioBucketList{}
+	if err = r.client.List(ctx, buckets, client.MatchingField(serverIndexField, instance.Spec.Server)); err != nil {
+		return "", fmt.Errorf("r.client.List: %w", err)
+	}
+	for _, item := range buckets.Items {
+		if item.UID != instance.UID && item.Spec.Name == instance.Spec.Name && utils.Contains(item.GetFinalizers(), minioBucketFinalizer) {
+			return fmt.Sprintf("Bucket is owned by MinioBucket %s", item.GetOwnerTag()), nil
+		}
+	}
+
+	if !instance.ShouldAdopt() {
+		return fmt.Sprintf("Bucket already exists, set adopt or the %s annotation to manage it", miniov1alpha1.AdoptAnnotation), nil
+	}
+	reqLogger.Info("Adopt existing bucket", "owner", owner)
+	return "", nil
+}
+
+// tagOwner record the MinioBucket in the owner tag of its bucket, keeping other tags
+func (r *ReconcileMinioBucket) tagOwner(ctx context.Context, reqLogger logr.Logger, instance *miniov1alpha1.MinioBucket, minioClient minioclient.BucketAdmin) error {
+	tags, err := minioClient.GetBucketTags(ctx, instance.Spec.Name)
+	if errors.Is(err, minioclient.ErrTaggingNotSupported) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("minioClient.GetBucketTags: %w", err)
+	}
+	if tags[miniov1alpha1.OwnerTag] == instance.GetOwnerTag() {
+		return nil
+	}
+
+	reqLogger.Info("Set bucket owner tag")
+	tags[miniov1alpha1.OwnerTag] = instance.GetOwnerTag()
+	if err = minioClient.SetBucketTags(ctx, instance.Spec.Name, tags); err != nil {
+		return fmt.Errorf("minioClient.SetBucketTags: %w", err)
+	}
+	reqLogger.Info("Bucket owner tag set")
+	return nil
+}
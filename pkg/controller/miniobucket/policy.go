@@ -0,0 +1,21 @@
+package miniobucket
+
+import (
+	"fmt"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/policy"
+)
+
+// desiredPolicy return the policy document of a MinioBucket, its inline policy merged with its policy rules and presets
+func desiredPolicy(instance *miniov1alpha1.MinioBucket) (string, error) {
+	rendered, err := policy.RenderBucket(instance.Spec.Name, instance.Spec.PolicyRules, instance.Spec.PolicyPresets)
+	if err != nil {
+		return "", fmt.Errorf("policy.RenderBucket: %w", err)
+	}
+	document, err := policy.Combine(instance.Spec.Policy, rendered)
+	if err != nil {
+		return "", fmt.Errorf("policy.Combine: %w", err)
+	}
+	return document, nil
+}
@@ -0,0 +1,132 @@
+package miniobucket
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/metrics"
+	"github.com/robotinfra/minio-resources-operator/pkg/minioclient"
+)
+
+// minUsageInterval is the minimum interval between two collections of the usage of a bucket
+const minUsageInterval = time.Minute
+
+// usageCache keep the bucket sizes of MinioServers, to fetch the data usage of a server
+// once per interval for all its buckets
+type usageCache struct {
+	mu      sync.Mutex
+	entries map[string]*usageEntry
+}
+
+// usageEntry is the bucket sizes of a MinioServer, and the time they were fetched. Its lock is held
+// while fetching, so a slow server doesn't block the collection of the other servers.
+type usageEntry struct {
+	mu    sync.Mutex
+	time  time.Time
+	sizes map[string]uint64
+}
+
+// newUsageCache return an empty usageCache
+func newUsageCache() *usageCache {
+	return &usageCache{entries: map[string]*usageEntry{}}
+}
+
+// entry return the usageEntry of a MinioServer, created if missing
+func (c *usageCache) entry(server string) *usageEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[server]
+	if !ok {
+		entry = &usageEntry{}
+		c.entries[server] = entry
+	}
+	return entry
+}
+
+// bucketSizes return the bucket sizes of a MinioServer, fetched if older than interval
+func (c *usageCache) bucketSizes(ctx context.Context, minioClients minioclient.Clients, server *miniov1alpha1.MinioServer, interval time.Duration) (map[string]uint64, error) {
+	entry := c.entry(server.Name)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if !entry.time.IsZero() && time.Since(entry.time) < interval {
+		return entry.sizes, nil
+	}
+
+	minioAdminClient, err := minioClients.ServerAdmin(ctx, server)
+	if err != nil {
+		return nil, fmt.Errorf("minioClients.ServerAdmin: %w", err)
+	}
+	usage, err := minioAdminClient.DataUsageInfo()
+	if err != nil {
+		return nil, fmt.Errorf("minioAdminClient.DataUsageInfo: %w", err)
+	}
+	entry.time, entry.sizes = time.Now(), usage.BucketsSizes
+	return usage.BucketsSizes, nil
+}
+
+// usageInterval return the interval between two collections of bucket usage of a MinioServer, 0 if disabled
+func usageInterval(server *miniov1alpha1.MinioServer) time.Duration {
+	if server.Spec.BucketUsageInterval == nil {
+		return 0
+	}
+	if interval := server.Spec.BucketUsageInterval.Duration; interval > minUsageInterval {
+		return interval
+	}
+	return minUsageInterval
+}
+
+// reconcileUsage collect the usage of a bucket once per interval of its MinioServer, and mirror it in the
+// MinioBucket status and metrics. It return the delay before the next collection, 0 if disabled.
+// Only the data usage computed by the Minio server is used, objects are not listed. A bucket is missing
+// from the data usage until the server computes it. Failures are reported in the UsageCollected condition,
+// the usage being optional.
+func (r *ReconcileMinioBucket) reconcileUsage(ctx context.Context, reqLogger logr.Logger, instance *miniov1alpha1.MinioBucket, minioServer *miniov1alpha1.MinioServer) time.Duration {
+	interval := usageInterval(minioServer)
+	if interval == 0 {
+		if instance.Status.Usage != nil {
+			reqLogger.Info("Bucket usage is not collected anymore, remove it")
+			instance.Status.Usage = nil
+			metrics.DeleteBucketUsage(instance.Namespace, instance.Name, instance.Spec.Server, instance.Spec.Name)
+		}
+		instance.Status.Conditions.RemoveCondition(miniov1alpha1.ConditionUsageCollected)
+		return 0
+	}
+
+	if usage := instance.Status.Usage; usage != nil {
+		if elapsed := time.Since(usage.LastUpdateTime.Time); elapsed < interval {
+			metrics.SetBucketUsage(instance.Namespace, instance.Name, instance.Spec.Server, instance.Spec.Name, usage.Size)
+			return interval - elapsed
+		}
+	}
+
+	reqLogger.Info("Collect bucket usage")
+	sizes, err := r.usage.bucketSizes(ctx, r.minioClients, minioServer, interval)
+	if err != nil {
+		reqLogger.Info("Bucket usage collection failed", "error", err.Error())
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionUsageCollected, corev1.ConditionFalse, "CollectFailed", err.Error())
+		return interval
+	}
+
+	size, ok := sizes[instance.Spec.Name]
+	if !ok {
+		reqLogger.Info("Bucket usage not computed by the Minio server yet")
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionUsageCollected, corev1.ConditionFalse, "NotComputed", "Bucket not in the data usage computed by the Minio server yet")
+		return interval
+	}
+
+	instance.Status.Usage = &miniov1alpha1.BucketUsage{
+		Size:           int64(size),
+		LastUpdateTime: metav1.Now(),
+	}
+	metrics.SetBucketUsage(instance.Namespace, instance.Name, instance.Spec.Server, instance.Spec.Name, instance.Status.Usage.Size)
+	instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionUsageCollected, corev1.ConditionTrue, "Collected", "")
+	reqLogger.Info("Bucket usage collected", "size", instance.Status.Usage.Size)
+	return interval
+}
@@ -0,0 +1,61 @@
+package miniobucket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/minioclient"
+)
+
+// reconcileVersioning converge the versioning state of a bucket to the MinioBucket spec.
+// A server not supporting versioning is reported in the VersioningApplied condition, not as an error,
+// as retrying would not help.
+func (r *ReconcileMinioBucket) reconcileVersioning(ctx context.Context, reqLogger logr.Logger, instance *miniov1alpha1.MinioBucket, minioClient minioclient.BucketAdmin) error {
+	reqLogger.Info("Get bucket versioning")
+	versioning, err := minioClient.GetBucketVersioning(ctx, instance.Spec.Name)
+	if errors.Is(err, minioclient.ErrVersioningNotSupported) {
+		instance.Status.Versioning = ""
+		if instance.Spec.Versioning == "" {
+			instance.Status.Conditions.RemoveCondition(miniov1alpha1.ConditionVersioningApplied)
+		} else {
+			reqLogger.Info("Bucket versioning is not supported by the server")
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionVersioningApplied, corev1.ConditionFalse, "NotSupported", err.Error())
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("minioClient.GetBucketVersioning: %w", err)
+	}
+	instance.Status.Versioning = versioning
+
+	if instance.Spec.Versioning == "" {
+		instance.Status.Conditions.RemoveCondition(miniov1alpha1.ConditionVersioningApplied)
+		return nil
+	}
+
+	if versioning == string(instance.Spec.Versioning) {
+		reqLogger.Info("Bucket versioning is already correct")
+	} else {
+		reqLogger.Info("Bucket versioning is different, set it", "versioning", instance.Spec.Versioning)
+		err = minioClient.SetBucketVersioning(ctx, instance.Spec.Name, string(instance.Spec.Versioning))
+		if errors.Is(err, minioclient.ErrVersioningNotSupported) {
+			reqLogger.Info("Bucket versioning is not supported by the server")
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionVersioningApplied, corev1.ConditionFalse, "NotSupported", err.Error())
+			return nil
+		}
+		if err != nil {
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionVersioningApplied, corev1.ConditionFalse, "SetVersioningFailed", err.Error())
+			return fmt.Errorf("minioClient.SetBucketVersioning: %w", err)
+		}
+		instance.Status.Versioning = string(instance.Spec.Versioning)
+		reqLogger.Info("Bucket versioning set")
+		recordDrift(instance, "versioning")
+	}
+	instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionVersioningApplied, corev1.ConditionTrue, "VersioningApplied", "")
+	return nil
+}
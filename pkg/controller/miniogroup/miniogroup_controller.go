@@ -0,0 +1,486 @@
+package miniogroup
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/minio/minio/pkg/madmin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/controller/minioserver"
+	"github.com/robotinfra/minio-resources-operator/pkg/minioclient"
+	"github.com/robotinfra/minio-resources-operator/pkg/utils"
+)
+
+var log = logf.Log.WithName("controller_miniogroup")
+
+const (
+	minioGroupFinalizer = "finalizer.group.minio.robotinfra.com"
+
+	// serverOfflineRequeueDelay is the delay before retrying when the MinioServer is offline
+	serverOfflineRequeueDelay = time.Minute
+
+	// serverIndexField is the field indexing MinioGroup by MinioServer name
+	serverIndexField = "spec.server"
+
+	// usersIndexField is the field indexing MinioGroup by MinioUser members names
+	usersIndexField = "spec.users"
+
+	// noSuchGroupCode is the Minio admin API error code of a missing group
+	noSuchGroupCode = "XMinioAdminNoSuchGroup"
+)
+
+// Add creates a new MinioGroup Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager, minioClients minioclient.Clients) error {
+	return add(mgr, newReconciler(mgr, minioClients))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, minioClients minioclient.Clients) reconcile.Reconciler {
+	return &ReconcileMinioGroup{client: mgr.GetClient(), scheme: mgr.GetScheme(), minioClients: minioClients}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("miniogroup-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return fmt.Errorf("controller.New: %w", err)
+	}
+
+	// Index MinioGroup by MinioServer, to find them when a MinioServer changes
+	err = mgr.GetFieldIndexer().IndexField(&miniov1alpha1.MinioGroup{}, serverIndexField, func(o runtime.Object) []string {
+		return []string{o.(*miniov1alpha1.MinioGroup).Spec.Server}
+	})
+	if err != nil {
+		return fmt.Errorf("mgr.GetFieldIndexer().IndexField: %w", err)
+	}
+
+	// Index MinioGroup by MinioUser members, to find them when a MinioUser changes
+	err = mgr.GetFieldIndexer().IndexField(&miniov1alpha1.MinioGroup{}, usersIndexField, func(o runtime.Object) []string {
+		return o.(*miniov1alpha1.MinioGroup).Spec.Users
+	})
+	if err != nil {
+		return fmt.Errorf("mgr.GetFieldIndexer().IndexField: %w", err)
+	}
+
+	// Watch for changes to primary resource MinioGroup
+	err = c.Watch(&source.Kind{Type: &miniov1alpha1.MinioGroup{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return fmt.Errorf("c.Watch: %w", err)
+	}
+
+	// Watch for changes to MinioUser and requeue the MinioGroup it is member of
+	err = c.Watch(&source.Kind{Type: &miniov1alpha1.MinioUser{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: userToRequests(mgr.GetClient()),
+	})
+	if err != nil {
+		return fmt.Errorf("c.Watch: %w", err)
+	}
+
+	// Watch for changes to MinioServer and requeue all its MinioGroup
+	err = c.Watch(&source.Kind{Type: &miniov1alpha1.MinioServer{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			return serversToRequests(mgr.GetClient(), []string{a.Meta.GetName()})
+		}),
+	}, minioserver.DependentsPredicate)
+	if err != nil {
+		return fmt.Errorf("c.Watch: %w", err)
+	}
+
+	// Watch for changes to MinioServer credentials Secrets
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: secretToRequests(mgr.GetClient()),
+	})
+	if err != nil {
+		return fmt.Errorf("c.Watch: %w", err)
+	}
+
+	return nil
+}
+
+// userToRequests map a MinioUser to all MinioGroup of its namespace having it as member
+func userToRequests(c client.Client) handler.ToRequestsFunc {
+	return func(a handler.MapObject) []reconcile.Request {
+		groups := &miniov1alpha1.MinioGroupList{}
+		if err := c.List(context.TODO(), groups, client.InNamespace(a.Meta.GetNamespace()), client.MatchingField(usersIndexField, a.Meta.GetName())); err != nil {
+			log.Error(err, "c.List")
+			return nil
+		}
+		return groupsToRequests(groups)
+	}
+}
+
+// secretToRequests map a Secret to all MinioGroup of the MinioServer using it as credentials
+func secretToRequests(c client.Client) handler.ToRequestsFunc {
+	return func(a handler.MapObject) []reconcile.Request {
+		servers, err := minioclient.ServersUsingSecret(context.TODO(), c, a.Meta.GetNamespace(), a.Meta.GetName())
+		if err != nil {
+			log.Error(err, "minioclient.ServersUsingSecret")
+			return nil
+		}
+		return serversToRequests(c, servers)
+	}
+}
+
+// serversToRequests return a request for all MinioGroup of a list of MinioServer
+func serversToRequests(c client.Client, servers []string) []reconcile.Request {
+	requests := []reconcile.Request{}
+	for _, server := range servers {
+		groups := &miniov1alpha1.MinioGroupList{}
+		if err := c.List(context.TODO(), groups, client.MatchingField(serverIndexField, server)); err != nil {
+			log.Error(err, "c.List")
+			continue
+		}
+		requests = append(requests, groupsToRequests(groups)...)
+	}
+	return requests
+}
+
+// groupsToRequests return a request for each MinioGroup of a list
+func groupsToRequests(groups *miniov1alpha1.MinioGroupList) []reconcile.Request {
+	requests := []reconcile.Request{}
+	for _, item := range groups.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: item.Namespace,
+			Name:      item.Name,
+		}})
+	}
+	return requests
+}
+
+// blank assignment to verify that ReconcileMinioGroup implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileMinioGroup{}
+
+// ReconcileMinioGroup reconciles a MinioGroup object
+type ReconcileMinioGroup struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client       client.Client
+	scheme       *runtime.Scheme
+	minioClients minioclient.Clients
+}
+
+// Reconcile reads that state of the cluster for a MinioGroup object and makes changes based on the state read
+// and what is in the MinioGroup.Spec
+// Note:
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileMinioGroup) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling MinioGroup")
+
+	// Fetch the MinioGroup instance
+	instance := &miniov1alpha1.MinioGroup{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, fmt.Errorf("r.client.Get: %w", err)
+	}
+	originalStatus := instance.Status.DeepCopy()
+
+	result, err := r.reconcileGroup(reqLogger, instance)
+	if err != nil {
+		instance.Status.Conditions.SetReconcileFailed(err)
+	}
+
+	if instance.GetDeletionTimestamp() != nil && !utils.Contains(instance.GetFinalizers(), minioGroupFinalizer) {
+		// Object is being deleted, there is no status to update
+		return result, err
+	}
+
+	if !equality.Semantic.DeepEqual(originalStatus, &instance.Status) {
+		reqLogger.Info("Update status")
+		if updateErr := r.client.Status().Update(context.TODO(), instance); updateErr != nil && err == nil {
+			return reconcile.Result{}, fmt.Errorf("r.client.Status().Update: %w", updateErr)
+		}
+	}
+
+	return result, err
+}
+
+// reconcileGroup converge the Minio group to the MinioGroup spec, and record observations in its status
+func (r *ReconcileMinioGroup) reconcileGroup(reqLogger logr.Logger, instance *miniov1alpha1.MinioGroup) (reconcile.Result, error) {
+	reqLogger = reqLogger.WithValues("Minio.Group", instance.Spec.Name)
+
+	minioServer := &miniov1alpha1.MinioServer{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{
+		Name: instance.Spec.Server,
+	}, minioServer); err != nil {
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionServerReachable, corev1.ConditionFalse, "ServerNotFound", err.Error())
+		return reconcile.Result{}, fmt.Errorf("r.client.Get: %w", err)
+	}
+
+	if minioServer.IsOffline() {
+		reqLogger.Info("Minio server is offline, wait for it to be online")
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionServerReachable, corev1.ConditionFalse, "ServerOffline", minioServer.Status.LastError)
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionReady, corev1.ConditionFalse, "ServerOffline", fmt.Sprintf("MinioServer %s is offline", minioServer.Name))
+		return reconcile.Result{RequeueAfter: serverOfflineRequeueDelay}, nil
+	}
+
+	minioAdminClient, err := r.minioClients.IAMAdmin(context.TODO(), minioServer)
+	if err != nil {
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionServerReachable, corev1.ConditionFalse, "ClientFailed", err.Error())
+		return reconcile.Result{}, fmt.Errorf("r.minioClients.IAMAdmin: %w", err)
+	}
+
+	reqLogger.Info("Get Minio group")
+	group, err := minioAdminClient.GetGroupDescription(instance.Spec.Name)
+	isGroupExists := true
+	if err != nil {
+		if madmin.ToErrorResponse(err).Code != noSuchGroupCode {
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionServerReachable, corev1.ConditionFalse, "RequestFailed", err.Error())
+			return reconcile.Result{}, fmt.Errorf("minioAdminClient.GetGroupDescription: %w", err)
+		}
+		isGroupExists = false
+		group = &madmin.GroupDesc{Name: instance.Spec.Name}
+	}
+	instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionServerReachable, corev1.ConditionTrue, "Reachable", "")
+	reqLogger.Info("Got group", "exists", isGroupExists)
+
+	finalizerPresent := utils.Contains(instance.GetFinalizers(), minioGroupFinalizer)
+
+	// An existing group is only managed once owned, the finalizer is added after this check
+	if instance.GetDeletionTimestamp() == nil && isGroupExists && !finalizerPresent {
+		conflict, err := r.checkOwnership(context.TODO(), reqLogger, instance)
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("r.checkOwnership: %w", err)
+		}
+		if conflict != "" {
+			reqLogger.Info("Group is not owned by the MinioGroup", "reason", conflict)
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionConflict, corev1.ConditionTrue, "NotOwned", conflict)
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionReady, corev1.ConditionFalse, "Conflict", conflict)
+			return reconcile.Result{}, nil
+		}
+	}
+
+	if instance.GetDeletionTimestamp() != nil {
+		if finalizerPresent {
+			// Run finalization logic for. If the
+			// finalization logic fails, don't remove the finalizer so
+			// that we can retry during the next reconciliation.
+			if isGroupExists {
+				reqLogger.Info("Instance marked for deletion, remove Minio group")
+				if err = removeGroup(minioAdminClient, group); err != nil {
+					return reconcile.Result{}, err
+				}
+				reqLogger.Info("Minio group removed")
+			} else {
+				reqLogger.Info("Minio group already removed")
+			}
+
+			// Remove minioGroupFinalizer. Once all finalizers have been
+			// removed, the object will be deleted.
+			reqLogger.Info("Delete finalizer")
+			instance.SetFinalizers(utils.Remove(instance.GetFinalizers(), minioGroupFinalizer))
+			if err = r.client.Update(context.TODO(), instance); err != nil {
+				return reconcile.Result{}, fmt.Errorf("r.client.Update: %w", err)
+			}
+			reqLogger.Info("Finalizer deleted")
+		} else {
+			reqLogger.Info("Instance marked for deletion, but not minioGroupFinalizer")
+		}
+		return reconcile.Result{}, nil
+	}
+
+	if err := controllerutil.SetControllerReference(minioServer, instance, r.scheme); err != nil {
+		return reconcile.Result{}, fmt.Errorf("controllerutil.SetControllerReference: %w", err)
+	}
+
+	if !finalizerPresent {
+		reqLogger.Info("No finalizer, add it")
+		// Update replaces instance with the stored object, keep the status observed by this reconcile
+		status := instance.Status.DeepCopy()
+		instance.SetFinalizers(append(instance.GetFinalizers(), minioGroupFinalizer))
+		if err = r.client.Update(context.TODO(), instance); err != nil {
+			return reconcile.Result{}, fmt.Errorf("r.client.Update: %w", err)
+		}
+		instance.Status = *status
+		reqLogger.Info("Finalizer added")
+	}
+	instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionConflict, corev1.ConditionFalse, "Owned", "")
+
+	members, pendingMembers, pendingUsers, err := r.groupMembers(instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	membersToAdd := difference(members, group.Members)
+	if !isGroupExists || len(membersToAdd) > 0 {
+		reqLogger.Info("Add members to group", "members", membersToAdd)
+		if err = minioAdminClient.UpdateGroupMembers(madmin.GroupAddRemove{
+			Group:   instance.Spec.Name,
+			Members: membersToAdd,
+		}); err != nil {
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionMembersSynced, corev1.ConditionFalse, "AddMembersFailed", err.Error())
+			return reconcile.Result{}, fmt.Errorf("minioAdminClient.UpdateGroupMembers: %w", err)
+		}
+		reqLogger.Info("Members added")
+		if !isGroupExists {
+			group.Status = string(madmin.GroupEnabled)
+		}
+	}
+
+	// Members of MinioUser not ready yet are kept, a transient failure of a MinioUser doesn't remove it from the group.
+	// Only remove a non empty members list, an empty one removes the group
+	if membersToRemove := difference(difference(group.Members, members), pendingMembers); len(membersToRemove) > 0 {
+		reqLogger.Info("Remove members from group", "members", membersToRemove)
+		if err = minioAdminClient.UpdateGroupMembers(madmin.GroupAddRemove{
+			Group:    instance.Spec.Name,
+			Members:  membersToRemove,
+			IsRemove: true,
+		}); err != nil {
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionMembersSynced, corev1.ConditionFalse, "RemoveMembersFailed", err.Error())
+			return reconcile.Result{}, fmt.Errorf("minioAdminClient.UpdateGroupMembers: %w", err)
+		}
+		reqLogger.Info("Members removed")
+	}
+	for _, accessKey := range pendingMembers {
+		if utils.Contains(group.Members, accessKey) && !utils.Contains(members, accessKey) {
+			members = append(members, accessKey)
+		}
+	}
+	sort.Strings(members)
+	instance.Status.Members = members
+	if len(pendingUsers) > 0 {
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionMembersSynced, corev1.ConditionFalse, "UsersNotReady", fmt.Sprintf("MinioUser not ready: %s", strings.Join(pendingUsers, ", ")))
+	} else {
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionMembersSynced, corev1.ConditionTrue, "MembersSynced", "")
+	}
+
+	groupStatus := madmin.GroupEnabled
+	if instance.Spec.Disabled {
+		groupStatus = madmin.GroupDisabled
+	}
+	if group.Status != string(groupStatus) {
+		reqLogger.Info("Set group status", "status", groupStatus)
+		if err = minioAdminClient.SetGroupStatus(instance.Spec.Name, groupStatus); err != nil {
+			return reconcile.Result{}, fmt.Errorf("minioAdminClient.SetGroupStatus: %w", err)
+		}
+		reqLogger.Info("Group status set")
+	}
+	instance.Status.GroupStatus = string(groupStatus)
+
+	switch {
+	case instance.Spec.Policy == "" && group.Policy != "":
+		reqLogger.Info("Detach group policy", "policy", group.Policy)
+		// Minio removes the policy mapping of a group set to an empty policy name
+		if err = minioAdminClient.SetPolicy("", instance.Spec.Name, true); err != nil {
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionPolicyApplied, corev1.ConditionFalse, "DetachPolicyFailed", err.Error())
+			return reconcile.Result{}, fmt.Errorf("minioAdminClient.SetPolicy: %w", err)
+		}
+		reqLogger.Info("Group policy detached")
+	case group.Policy != instance.Spec.Policy:
+		reqLogger.Info("Set group policy", "policy", instance.Spec.Policy)
+		if err = minioAdminClient.SetPolicy(instance.Spec.Policy, instance.Spec.Name, true); err != nil {
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionPolicyApplied, corev1.ConditionFalse, "SetPolicyFailed", err.Error())
+			return reconcile.Result{}, fmt.Errorf("minioAdminClient.SetPolicy: %w", err)
+		}
+		reqLogger.Info("Group policy set")
+	}
+	if instance.Spec.Policy == "" {
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionPolicyApplied, corev1.ConditionFalse, "NoPolicy", "No policy in spec")
+	} else {
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionPolicyApplied, corev1.ConditionTrue, "PolicyApplied", "")
+	}
+
+	instance.Status.ObservedGeneration = instance.GetGeneration()
+	if len(pendingUsers) > 0 {
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionReady, corev1.ConditionFalse, "UsersNotReady", "Waiting for MinioUser members to be ready")
+		reqLogger.Info("MinioGroup reconcilied, waiting for users", "users", pendingUsers)
+		return reconcile.Result{}, nil
+	}
+	instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionReady, corev1.ConditionTrue, "Reconciled", "")
+	reqLogger.Info("MinioGroup reconcilied")
+	return reconcile.Result{}, nil
+}
+
+// groupMembers return the sorted access keys of the group members, the access keys of the MinioUser not ready
+// to be members yet, and the names of the MinioUser not ready or not found
+func (r *ReconcileMinioGroup) groupMembers(instance *miniov1alpha1.MinioGroup) ([]string, []string, []string, error) {
+	members := append([]string{}, instance.Spec.AccessKeys...)
+	pendingMembers := []string{}
+	pendingUsers := []string{}
+	for _, name := range instance.Spec.Users {
+		user := &miniov1alpha1.MinioUser{}
+		if err := r.client.Get(context.TODO(), types.NamespacedName{
+			Namespace: instance.Namespace,
+			Name:      name,
+		}, user); err != nil {
+			if errors.IsNotFound(err) {
+				pendingUsers = append(pendingUsers, name)
+				continue
+			}
+			return nil, nil, nil, fmt.Errorf("r.client.Get: %w", err)
+		}
+		if user.Spec.Server != instance.Spec.Server {
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionMembersSynced, corev1.ConditionFalse, "ServerMismatch", fmt.Sprintf("MinioUser %s is on MinioServer %s", name, user.Spec.Server))
+			return nil, nil, nil, fmt.Errorf("MinioUser %s is on MinioServer %s, not %s", name, user.Spec.Server, instance.Spec.Server)
+		}
+		// Minio refuses members that are not yet created
+		if user.GetDeletionTimestamp() != nil || !user.Status.Conditions.IsTrueFor(miniov1alpha1.ConditionReady) {
+			pendingMembers = append(pendingMembers, user.Spec.AccessKey)
+			pendingUsers = append(pendingUsers, name)
+			continue
+		}
+		if !utils.Contains(members, user.Spec.AccessKey) {
+			members = append(members, user.Spec.AccessKey)
+		}
+	}
+	sort.Strings(members)
+	return members, pendingMembers, pendingUsers, nil
+}
+
+// removeGroup remove all members of a Minio group, then the group itself
+func removeGroup(minioAdminClient minioclient.IAMAdmin, group *madmin.GroupDesc) error {
+	if len(group.Members) > 0 {
+		if err := minioAdminClient.UpdateGroupMembers(madmin.GroupAddRemove{
+			Group:    group.Name,
+			Members:  group.Members,
+			IsRemove: true,
+		}); err != nil {
+			return fmt.Errorf("minioAdminClient.UpdateGroupMembers: %w", err)
+		}
+	}
+	// Removing an empty members list from an empty group removes the group
+	if err := minioAdminClient.UpdateGroupMembers(madmin.GroupAddRemove{
+		Group:    group.Name,
+		Members:  []string{},
+		IsRemove: true,
+	}); err != nil {
+		return fmt.Errorf("minioAdminClient.UpdateGroupMembers: %w", err)
+	}
+	return nil
+}
+
+// difference return the items of a not in b
+func difference(a, b []string) []string {
+	result := []string{}
+	for _, item := range a {
+		if !utils.Contains(b, item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
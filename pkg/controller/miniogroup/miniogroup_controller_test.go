@@ -0,0 +1,190 @@
+package miniogroup
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/minio/minio/pkg/madmin"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/controller/internal/controllertest"
+	miniofake "github.com/robotinfra/minio-resources-operator/pkg/minioclient/fake"
+)
+
+var request = reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "group"}}
+
+// newTestReconciler return a reconciler using a fake Kubernetes client with objects, and a fake Minio server
+func newTestReconciler(t *testing.T, objs ...runtime.Object) (*ReconcileMinioGroup, *miniofake.Server) {
+	c, s := controllertest.NewClient(t, objs...)
+	server := miniofake.NewServer()
+	return &ReconcileMinioGroup{
+		client:       c,
+		scheme:       s,
+		minioClients: server,
+	}, server
+}
+
+func newMinioGroup() *miniov1alpha1.MinioGroup {
+	return &miniov1alpha1.MinioGroup{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "group", UID: "group-uid"},
+		Spec: miniov1alpha1.MinioGroupSpec{
+			Server: "test",
+			Name:   "mygroup",
+			Users:  []string{"user"},
+		},
+	}
+}
+
+// newReadyMinioUser return a MinioUser with a Ready condition
+func newReadyMinioUser() *miniov1alpha1.MinioUser {
+	user := &miniov1alpha1.MinioUser{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "user"},
+		Spec:       miniov1alpha1.MinioUserSpec{Server: "test", AccessKey: "myUsername"},
+	}
+	user.Status.Conditions.SetCondition(miniov1alpha1.ConditionReady, corev1.ConditionTrue, "Reconciled", "")
+	return user
+}
+
+// reconcileOK reconcile the MinioGroup of the request, fail on error, and return it
+func reconcileOK(t *testing.T, r *ReconcileMinioGroup) *miniov1alpha1.MinioGroup {
+	t.Helper()
+	controllertest.ReconcileOK(t, r, request)
+	instance := &miniov1alpha1.MinioGroup{}
+	controllertest.Get(t, r.client, request, instance)
+	return instance
+}
+
+// assertMembers fail if the members of the Minio group and the MinioGroup status are not the expected ones
+func assertMembers(t *testing.T, server *miniofake.Server, instance *miniov1alpha1.MinioGroup, expected ...string) {
+	t.Helper()
+	group, _ := server.GetGroup("mygroup")
+	if strings.Join(group.Members, ",") != strings.Join(expected, ",") {
+		t.Errorf("group members are %v, expected %v", group.Members, expected)
+	}
+	if strings.Join(instance.Status.Members, ",") != strings.Join(expected, ",") {
+		t.Errorf("status members are %v, expected %v", instance.Status.Members, expected)
+	}
+}
+
+func TestReconcileMembers(t *testing.T) {
+	user := newReadyMinioUser()
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), newMinioGroup(), user)
+	if err := server.AddUser("myUsername", "mySecurePassword"); err != nil {
+		t.Fatalf("server.AddUser: %v", err)
+	}
+
+	instance := reconcileOK(t, r)
+	assertMembers(t, server, instance, "myUsername")
+
+	// A MinioUser failing to reconcile stays in the group
+	user.Status.Conditions.SetCondition(miniov1alpha1.ConditionReady, corev1.ConditionFalse, "ServerOffline", "")
+	controllertest.Update(t, r.client, user)
+	instance = reconcileOK(t, r)
+	assertMembers(t, server, instance, "myUsername")
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionMembersSynced, corev1.ConditionFalse, "UsersNotReady")
+
+	// A deleted MinioUser is removed from the group
+	if err := r.client.Delete(context.TODO(), user); err != nil {
+		t.Fatalf("r.client.Delete: %v", err)
+	}
+	instance = reconcileOK(t, r)
+	assertMembers(t, server, instance)
+}
+
+func TestReconcileMembersUndeclared(t *testing.T) {
+	user := newReadyMinioUser()
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), newMinioGroup(), user)
+	if err := server.AddUser("myUsername", "mySecurePassword"); err != nil {
+		t.Fatalf("server.AddUser: %v", err)
+	}
+	reconcileOK(t, r)
+
+	// A MinioUser not declared anymore is removed from the group, even when not ready
+	user.Status.Conditions.SetCondition(miniov1alpha1.ConditionReady, corev1.ConditionFalse, "ReconcileFailed", "")
+	controllertest.Update(t, r.client, user)
+	instance := &miniov1alpha1.MinioGroup{}
+	controllertest.Get(t, r.client, request, instance)
+	instance.Spec.Users = nil
+	instance.Spec.AccessKeys = []string{"other"}
+	if err := server.AddUser("other", "mySecurePassword"); err != nil {
+		t.Fatalf("server.AddUser: %v", err)
+	}
+	controllertest.Update(t, r.client, instance)
+
+	instance = reconcileOK(t, r)
+	assertMembers(t, server, instance, "other")
+}
+
+func TestReconcileConflict(t *testing.T) {
+	deleted := newMinioGroup()
+	now := metav1.Now()
+	deleted.DeletionTimestamp = &now
+	for _, instance := range []*miniov1alpha1.MinioGroup{newMinioGroup(), deleted} {
+		r, server := newTestReconciler(t, controllertest.NewMinioServer(), instance, newReadyMinioUser())
+		for _, accessKey := range []string{"myUsername", "outsider"} {
+			if err := server.AddUser(accessKey, "mySecurePassword"); err != nil {
+				t.Fatalf("server.AddUser: %v", err)
+			}
+		}
+		if err := server.UpdateGroupMembers(madmin.GroupAddRemove{Group: "mygroup", Members: []string{"outsider"}}); err != nil {
+			t.Fatalf("server.UpdateGroupMembers: %v", err)
+		}
+		server.ResetCalls()
+
+		// A group created outside of the operator is neither changed nor removed unless adopted
+		instance = reconcileOK(t, r)
+		controllertest.AssertCallCount(t, server, "UpdateGroupMembers", 0)
+		if group, ok := server.GetGroup("mygroup"); !ok || strings.Join(group.Members, ",") != "outsider" {
+			t.Errorf("group is %+v, expected it unchanged", group)
+		}
+		controllertest.AssertFinalizer(t, instance, minioGroupFinalizer, false)
+		if instance.GetDeletionTimestamp() != nil {
+			continue
+		}
+		controllertest.AssertConflict(t, instance.Status.Conditions, "NotOwned")
+
+		instance.Spec.Adopt = true
+		controllertest.Update(t, r.client, instance)
+		instance = reconcileOK(t, r)
+		assertMembers(t, server, instance, "myUsername")
+		controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionConflict, corev1.ConditionFalse, "Owned")
+	}
+}
+
+func TestReconcilePolicy(t *testing.T) {
+	group := newMinioGroup()
+	group.Spec.Policy = "mybucket-read"
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), group, newReadyMinioUser())
+	if err := server.AddUser("myUsername", "mySecurePassword"); err != nil {
+		t.Fatalf("server.AddUser: %v", err)
+	}
+	if err := server.AddCannedPolicy("mybucket-read", `{"Version":"2012-10-17","Statement":[]}`); err != nil {
+		t.Fatalf("server.AddCannedPolicy: %v", err)
+	}
+
+	instance := reconcileOK(t, r)
+	if group, _ := server.GetGroup("mygroup"); group.Policy != "mybucket-read" {
+		t.Errorf("group policy is %q, expected mybucket-read", group.Policy)
+	}
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionPolicyApplied, corev1.ConditionTrue, "PolicyApplied")
+
+	// Clearing the policy detaches it from the group
+	instance.Spec.Policy = ""
+	controllertest.Update(t, r.client, instance)
+	instance = reconcileOK(t, r)
+	if group, _ := server.GetGroup("mygroup"); group.Policy != "" {
+		t.Errorf("group policy is %q, expected none", group.Policy)
+	}
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionPolicyApplied, corev1.ConditionFalse, "NoPolicy")
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionReady, corev1.ConditionTrue, "Reconciled")
+
+	server.ResetCalls()
+	reconcileOK(t, r)
+	controllertest.AssertCallCount(t, server, "SetPolicy", 0)
+}
@@ -0,0 +1,32 @@
+package miniogroup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/utils"
+)
+
+// checkOwnership return why a MinioGroup can't manage an existing Minio group, empty if it can.
+// Minio groups can't be tagged, a Minio group is owned by the MinioGroup holding the finalizer.
+func (r *ReconcileMinioGroup) checkOwnership(ctx context.Context, reqLogger logr.Logger, instance *miniov1alpha1.MinioGroup) (string, error) {
+	groups := &miniov1alpha1.MinioGroupList{}
+	if err := r.client.List(ctx, groups, client.MatchingField(serverIndexField, instance.Spec.Server)); err != nil {
+		return "", fmt.Errorf("r.client.List: %w", err)
+	}
+	for _, item := range groups.Items {
+		if item.UID != instance.UID && item.Spec.Server == instance.Spec.Server && item.Spec.Name == instance.Spec.Name && utils.Contains(item.GetFinalizers(), minioGroupFinalizer) {
+			return fmt.Sprintf("Group is owned by MinioGroup %s/%s", item.Namespace, item.Name), nil
+		}
+	}
+
+	if !instance.ShouldAdopt() {
+		return fmt.Sprintf("Group already exists, set adopt or the %s annotation to manage it", miniov1alpha1.AdoptAnnotation), nil
+	}
+	reqLogger.Info("Adopt existing group")
+	return "", nil
+}
@@ -0,0 +1,432 @@
+package miniopolicy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/controller/minioserver"
+	"github.com/robotinfra/minio-resources-operator/pkg/metrics"
+	"github.com/robotinfra/minio-resources-operator/pkg/minioclient"
+	"github.com/robotinfra/minio-resources-operator/pkg/policy"
+	"github.com/robotinfra/minio-resources-operator/pkg/utils"
+)
+
+var log = logf.Log.WithName("controller_miniopolicy")
+
+const (
+	minioPolicyFinalizer = "finalizer.policy.minio.robotinfra.com"
+
+	// serverOfflineRequeueDelay is the delay before retrying when the MinioServer is offline
+	serverOfflineRequeueDelay = time.Minute
+
+	// policyInUseRequeueDelay is the delay before retrying to delete a canned policy used by MinioUser or MinioGroup
+	policyInUseRequeueDelay = time.Minute
+
+	// serverIndexField is the field indexing MinioPolicy by MinioServer name
+	serverIndexField = "spec.server"
+
+	// nameIndexField is the field indexing MinioPolicy by canned policy, see policyKey
+	nameIndexField = "spec.name"
+
+	// userPoliciesIndexField is the field indexing MinioUser by the canned policies they use, see policyKey
+	userPoliciesIndexField = "spec.policies"
+
+	// groupPolicyIndexField is the field indexing MinioGroup by the canned policy they use, see policyKey
+	groupPolicyIndexField = "spec.policy"
+)
+
+// policyKey identify a canned policy by its MinioServer and name, canned policy names being global to a server
+func policyKey(server, name string) string {
+	return server + "/" + name
+}
+
+// Add creates a new MinioPolicy Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager, minioClients minioclient.Clients) error {
+	return add(mgr, newReconciler(mgr, minioClients))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, minioClients minioclient.Clients) reconcile.Reconciler {
+	return &ReconcileMinioPolicy{client: mgr.GetClient(), scheme: mgr.GetScheme(), minioClients: minioClients}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("miniopolicy-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return fmt.Errorf("controller.New: %w", err)
+	}
+
+	// Index MinioPolicy by MinioServer, to find them when a MinioServer changes
+	err = mgr.GetFieldIndexer().IndexField(&miniov1alpha1.MinioPolicy{}, serverIndexField, func(o runtime.Object) []string {
+		return []string{o.(*miniov1alpha1.MinioPolicy).Spec.Server}
+	})
+	if err != nil {
+		return fmt.Errorf("mgr.GetFieldIndexer().IndexField: %w", err)
+	}
+
+	// Index MinioPolicy by canned policy, to find the MinioPolicy sharing a canned policy
+	err = mgr.GetFieldIndexer().IndexField(&miniov1alpha1.MinioPolicy{}, nameIndexField, func(o runtime.Object) []string {
+		minioPolicy := o.(*miniov1alpha1.MinioPolicy)
+		return []string{policyKey(minioPolicy.Spec.Server, minioPolicy.Spec.Name)}
+	})
+	if err != nil {
+		return fmt.Errorf("mgr.GetFieldIndexer().IndexField: %w", err)
+	}
+
+	// Index MinioUser and MinioGroup by canned policy, to find the ones using a MinioPolicy
+	err = mgr.GetFieldIndexer().IndexField(&miniov1alpha1.MinioUser{}, userPoliciesIndexField, func(o runtime.Object) []string {
+		user := o.(*miniov1alpha1.MinioUser)
+		keys := []string{}
+		for _, name := range user.Spec.Policies {
+			keys = append(keys, policyKey(user.Spec.Server, name))
+		}
+		return keys
+	})
+	if err != nil {
+		return fmt.Errorf("mgr.GetFieldIndexer().IndexField: %w", err)
+	}
+	err = mgr.GetFieldIndexer().IndexField(&miniov1alpha1.MinioGroup{}, groupPolicyIndexField, func(o runtime.Object) []string {
+		group := o.(*miniov1alpha1.MinioGroup)
+		return []string{policyKey(group.Spec.Server, group.Spec.Policy)}
+	})
+	if err != nil {
+		return fmt.Errorf("mgr.GetFieldIndexer().IndexField: %w", err)
+	}
+
+	// Watch for changes to primary resource MinioPolicy
+	err = c.Watch(&source.Kind{Type: &miniov1alpha1.MinioPolicy{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return fmt.Errorf("c.Watch: %w", err)
+	}
+
+	// Watch for changes to MinioServer and requeue all its MinioPolicy
+	err = c.Watch(&source.Kind{Type: &miniov1alpha1.MinioServer{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			return serversToRequests(mgr.GetClient(), []string{a.Meta.GetName()})
+		}),
+	}, minioserver.DependentsPredicate)
+	if err != nil {
+		return fmt.Errorf("c.Watch: %w", err)
+	}
+
+	// Watch for changes to MinioServer credentials Secrets
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: secretToRequests(mgr.GetClient()),
+	})
+	if err != nil {
+		return fmt.Errorf("c.Watch: %w", err)
+	}
+
+	// Watch for changes to MinioPolicy and requeue the ones with the same canned policy,
+	// to manage it once its owner is deleted
+	err = c.Watch(&source.Kind{Type: &miniov1alpha1.MinioPolicy{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			minioPolicy, ok := a.Object.(*miniov1alpha1.MinioPolicy)
+			if !ok {
+				return nil
+			}
+			return policiesToRequests(mgr.GetClient(), minioPolicy.Spec.Server, []string{minioPolicy.Spec.Name})
+		}),
+	}, predicate.GenerationChangedPredicate{})
+	if err != nil {
+		return fmt.Errorf("c.Watch: %w", err)
+	}
+
+	// Watch for changes to MinioUser and MinioGroup and requeue the MinioPolicy they use,
+	// to delete them once unused
+	err = c.Watch(&source.Kind{Type: &miniov1alpha1.MinioUser{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			user, ok := a.Object.(*miniov1alpha1.MinioUser)
+			if !ok {
+				return nil
+			}
+			return policiesToRequests(mgr.GetClient(), user.Spec.Server, user.Spec.Policies)
+		}),
+	}, predicate.GenerationChangedPredicate{})
+	if err != nil {
+		return fmt.Errorf("c.Watch: %w", err)
+	}
+	err = c.Watch(&source.Kind{Type: &miniov1alpha1.MinioGroup{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			group, ok := a.Object.(*miniov1alpha1.MinioGroup)
+			if !ok {
+				return nil
+			}
+			return policiesToRequests(mgr.GetClient(), group.Spec.Server, []string{group.Spec.Policy})
+		}),
+	}, predicate.GenerationChangedPredicate{})
+	if err != nil {
+		return fmt.Errorf("c.Watch: %w", err)
+	}
+
+	return nil
+}
+
+// policiesToRequests return a request for all MinioPolicy of canned policies of a MinioServer
+func policiesToRequests(c client.Client, server string, names []string) []reconcile.Request {
+	requests := []reconcile.Request{}
+	for _, name := range names {
+		policies := &miniov1alpha1.MinioPolicyList{}
+		if err := c.List(context.TODO(), policies, client.MatchingField(nameIndexField, policyKey(server, name))); err != nil {
+			log.Error(err, "c.List")
+			continue
+		}
+		for _, item := range policies.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: item.Namespace,
+				Name:      item.Name,
+			}})
+		}
+	}
+	return requests
+}
+
+// secretToRequests map a Secret to all MinioPolicy of the MinioServer using it as credentials
+func secretToRequests(c client.Client) handler.ToRequestsFunc {
+	return func(a handler.MapObject) []reconcile.Request {
+		servers, err := minioclient.ServersUsingSecret(context.TODO(), c, a.Meta.GetNamespace(), a.Meta.GetName())
+		if err != nil {
+			log.Error(err, "minioclient.ServersUsingSecret")
+			return nil
+		}
+		return serversToRequests(c, servers)
+	}
+}
+
+// serversToRequests return a request for all MinioPolicy of a list of MinioServer
+func serversToRequests(c client.Client, servers []string) []reconcile.Request {
+	requests := []reconcile.Request{}
+	for _, server := range servers {
+		policies := &miniov1alpha1.MinioPolicyList{}
+		if err := c.List(context.TODO(), policies, client.MatchingField(serverIndexField, server)); err != nil {
+			log.Error(err, "c.List")
+			continue
+		}
+		for _, item := range policies.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: item.Namespace,
+				Name:      item.Name,
+			}})
+		}
+	}
+	return requests
+}
+
+// blank assignment to verify that ReconcileMinioPolicy implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileMinioPolicy{}
+
+// ReconcileMinioPolicy reconciles a MinioPolicy object
+type ReconcileMinioPolicy struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client       client.Client
+	scheme       *runtime.Scheme
+	minioClients minioclient.Clients
+}
+
+// Reconcile reads that state of the cluster for a MinioPolicy object and makes changes based on the state read
+// and what is in the MinioPolicy.Spec
+// Note:
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileMinioPolicy) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling MinioPolicy")
+
+	// Fetch the MinioPolicy instance
+	instance := &miniov1alpha1.MinioPolicy{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, fmt.Errorf("r.client.Get: %w", err)
+	}
+	originalStatus := instance.Status.DeepCopy()
+
+	result, err := r.reconcilePolicy(reqLogger, instance)
+	if err != nil {
+		instance.Status.Conditions.SetReconcileFailed(err)
+	}
+
+	if instance.GetDeletionTimestamp() != nil && !utils.Contains(instance.GetFinalizers(), minioPolicyFinalizer) {
+		// Object is being deleted, there is no status to update
+		return result, err
+	}
+
+	if !equality.Semantic.DeepEqual(originalStatus, &instance.Status) {
+		reqLogger.Info("Update status")
+		if updateErr := r.client.Status().Update(context.TODO(), instance); updateErr != nil && err == nil {
+			return reconcile.Result{}, fmt.Errorf("r.client.Status().Update: %w", updateErr)
+		}
+	}
+
+	return result, err
+}
+
+// reconcilePolicy converge the Minio canned policy to the MinioPolicy spec, and record observations in its status
+func (r *ReconcileMinioPolicy) reconcilePolicy(reqLogger logr.Logger, instance *miniov1alpha1.MinioPolicy) (reconcile.Result, error) {
+	reqLogger = reqLogger.WithValues("Minio.Policy", instance.Spec.Name)
+
+	minioServer := &miniov1alpha1.MinioServer{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{
+		Name: instance.Spec.Server,
+	}, minioServer); err != nil {
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionServerReachable, corev1.ConditionFalse, "ServerNotFound", err.Error())
+		return reconcile.Result{}, fmt.Errorf("r.client.Get: %w", err)
+	}
+
+	if minioServer.IsOffline() {
+		reqLogger.Info("Minio server is offline, wait for it to be online")
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionServerReachable, corev1.ConditionFalse, "ServerOffline", minioServer.Status.LastError)
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionReady, corev1.ConditionFalse, "ServerOffline", fmt.Sprintf("MinioServer %s is offline", minioServer.Name))
+		return reconcile.Result{RequeueAfter: serverOfflineRequeueDelay}, nil
+	}
+
+	minioAdminClient, err := r.minioClients.IAMAdmin(context.TODO(), minioServer)
+	if err != nil {
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionServerReachable, corev1.ConditionFalse, "ClientFailed", err.Error())
+		return reconcile.Result{}, fmt.Errorf("r.minioClients.IAMAdmin: %w", err)
+	}
+
+	reqLogger.Info("List all Minio policies")
+	allPolicies, err := minioAdminClient.ListCannedPolicies()
+	if err != nil {
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionServerReachable, corev1.ConditionFalse, "RequestFailed", err.Error())
+		return reconcile.Result{}, fmt.Errorf("minioAdminClient.ListCannedPolicies: %w", err)
+	}
+	instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionServerReachable, corev1.ConditionTrue, "Reachable", "")
+	reqLogger.Info("Got policy list")
+	existingPolicyBytes, isPolicyExists := allPolicies[instance.Spec.Name]
+	existingPolicy := string(existingPolicyBytes)
+
+	finalizerPresent := utils.Contains(instance.GetFinalizers(), minioPolicyFinalizer)
+
+	// A canned policy is only managed once owned, the finalizer is added after this check
+	if instance.GetDeletionTimestamp() == nil && !finalizerPresent {
+		conflict, err := r.checkOwnership(context.TODO(), reqLogger, instance, isPolicyExists)
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("r.checkOwnership: %w", err)
+		}
+		if conflict != "" {
+			reqLogger.Info("Canned policy is not owned by the MinioPolicy", "reason", conflict)
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionConflict, corev1.ConditionTrue, "NotOwned", conflict)
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionReady, corev1.ConditionFalse, "Conflict", conflict)
+			return reconcile.Result{}, nil
+		}
+	}
+
+	if instance.GetDeletionTimestamp() != nil {
+		if finalizerPresent {
+			// Run finalization logic for. If the
+			// finalization logic fails, don't remove the finalizer so
+			// that we can retry during the next reconciliation.
+			usedBy, err := r.usedBy(context.TODO(), instance)
+			if err != nil {
+				return reconcile.Result{}, fmt.Errorf("r.usedBy: %w", err)
+			}
+			if len(usedBy) != 0 {
+				message := fmt.Sprintf("Policy is used by %s", strings.Join(usedBy, ", "))
+				reqLogger.Info("Instance marked for deletion, wait for the Minio policy to be unused", "usedBy", usedBy)
+				instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionReady, corev1.ConditionFalse, "InUse", message)
+				return reconcile.Result{RequeueAfter: policyInUseRequeueDelay}, nil
+			}
+
+			if isPolicyExists {
+				reqLogger.Info("Instance marked for deletion, remove Minio canned policy")
+				if err = minioAdminClient.RemoveCannedPolicy(instance.Spec.Name); err != nil {
+					return reconcile.Result{}, fmt.Errorf("minioAdminClient.RemoveCannedPolicy: %w", err)
+				}
+				reqLogger.Info("Minio policy removed")
+			} else {
+				reqLogger.Info("Minio policy already removed")
+			}
+
+			// Remove minioPolicyFinalizer. Once all finalizers have been
+			// removed, the object will be deleted.
+			reqLogger.Info("Delete finalizer")
+			instance.SetFinalizers(utils.Remove(instance.GetFinalizers(), minioPolicyFinalizer))
+			if err = r.client.Update(context.TODO(), instance); err != nil {
+				return reconcile.Result{}, fmt.Errorf("r.client.Update: %w", err)
+			}
+			reqLogger.Info("Finalizer deleted")
+		} else {
+			reqLogger.Info("Instance marked for deletion, but not minioPolicyFinalizer")
+		}
+		return reconcile.Result{}, nil
+	}
+
+	if err := controllerutil.SetControllerReference(minioServer, instance, r.scheme); err != nil {
+		return reconcile.Result{}, fmt.Errorf("controllerutil.SetControllerReference: %w", err)
+	}
+
+	if !finalizerPresent {
+		reqLogger.Info("No finalizer, add it")
+		// Update replaces instance with the stored object, keep the status observed by this reconcile
+		status := instance.Status.DeepCopy()
+		instance.SetFinalizers(append(instance.GetFinalizers(), minioPolicyFinalizer))
+		if err = r.client.Update(context.TODO(), instance); err != nil {
+			return reconcile.Result{}, fmt.Errorf("r.client.Update: %w", err)
+		}
+		instance.Status = *status
+		reqLogger.Info("Finalizer added")
+	}
+	instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionConflict, corev1.ConditionFalse, "Owned", "")
+
+	isPolicyEqual := false
+	if isPolicyExists {
+		isPolicyEqual, err = policy.Equal(existingPolicy, instance.Spec.Policy)
+		if err != nil {
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionPolicyApplied, corev1.ConditionFalse, "InvalidPolicy", err.Error())
+			return reconcile.Result{}, fmt.Errorf("policy.Equal: %w", err)
+		}
+	}
+	if isPolicyEqual {
+		reqLogger.Info("Policy is correct state")
+	} else {
+		if isPolicyExists {
+			diff, _ := policy.Diff(existingPolicy, instance.Spec.Policy)
+			reqLogger.Info("Policy is different, replace it", "diff", diff)
+		} else {
+			reqLogger.Info("Policy is missing, add it")
+		}
+		if err = minioAdminClient.AddCannedPolicy(instance.Spec.Name, instance.Spec.Policy); err != nil {
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionPolicyApplied, corev1.ConditionFalse, "AddPolicyFailed", err.Error())
+			return reconcile.Result{}, fmt.Errorf("minioAdminClient.AddCannedPolicy: %w", err)
+		}
+		reqLogger.Info("Policy added")
+		// The spec was already applied, the policy was changed or removed outside of the operator
+		if instance.Status.ObservedGeneration == instance.GetGeneration() {
+			metrics.DriftCorrected(instance.Spec.Server, "MinioPolicy", "policy")
+		}
+	}
+	instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionPolicyApplied, corev1.ConditionTrue, "PolicyApplied", "")
+
+	instance.Status.ObservedGeneration = instance.GetGeneration()
+	instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionReady, corev1.ConditionTrue, "Reconciled", "")
+	reqLogger.Info("MinioPolicy reconcilied")
+	return reconcile.Result{}, nil
+}
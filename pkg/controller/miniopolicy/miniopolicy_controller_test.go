@@ -0,0 +1,174 @@
+package miniopolicy
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/controller/internal/controllertest"
+	miniofake "github.com/robotinfra/minio-resources-operator/pkg/minioclient/fake"
+)
+
+const testPolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:GetObject"],"Resource":["arn:aws:s3:::mybucket/*"]}]}`
+
+// newTestReconciler return a reconciler using a fake Kubernetes client with objects, and a fake Minio server
+func newTestReconciler(t *testing.T, objs ...runtime.Object) (*ReconcileMinioPolicy, *miniofake.Server) {
+	c, s := controllertest.NewClient(t, objs...)
+	server := miniofake.NewServer()
+	return &ReconcileMinioPolicy{
+		client:       c,
+		scheme:       s,
+		minioClients: server,
+	}, server
+}
+
+func newMinioPolicy(namespace string) *miniov1alpha1.MinioPolicy {
+	return &miniov1alpha1.MinioPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "policy", UID: types.UID(namespace + "-policy-uid")},
+		Spec: miniov1alpha1.MinioPolicySpec{
+			Server: "test",
+			Name:   "mybucket-read",
+			Policy: testPolicy,
+		},
+	}
+}
+
+// requestFor return the reconcile request of a MinioPolicy
+func requestFor(instance *miniov1alpha1.MinioPolicy) reconcile.Request {
+	return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}}
+}
+
+// reconcileOK reconcile a MinioPolicy, fail on error, and return it
+func reconcileOK(t *testing.T, r *ReconcileMinioPolicy, instance *miniov1alpha1.MinioPolicy) *miniov1alpha1.MinioPolicy {
+	t.Helper()
+	controllertest.ReconcileOK(t, r, requestFor(instance))
+	reconciled := &miniov1alpha1.MinioPolicy{}
+	controllertest.Get(t, r.client, requestFor(instance), reconciled)
+	return reconciled
+}
+
+func TestReconcileCreate(t *testing.T) {
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), newMinioPolicy("default"))
+
+	instance := reconcileOK(t, r, newMinioPolicy("default"))
+
+	if p, ok := server.GetPolicy("mybucket-read"); !ok || p != testPolicy {
+		t.Errorf("policy is %q, exists %v, expected %q", p, ok, testPolicy)
+	}
+	controllertest.AssertFinalizer(t, instance, minioPolicyFinalizer, true)
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionReady, corev1.ConditionTrue, "Reconciled")
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionConflict, corev1.ConditionFalse, "Owned")
+}
+
+func TestReconcileConflict(t *testing.T) {
+	other := newMinioPolicy("other")
+	other.Spec.Policy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:*"],"Resource":["arn:aws:s3:::*"]}]}`
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), newMinioPolicy("default"), other)
+	reconcileOK(t, r, newMinioPolicy("default"))
+
+	// A MinioPolicy of another namespace with the same name doesn't replace the policy
+	instance := reconcileOK(t, r, other)
+
+	if p, _ := server.GetPolicy("mybucket-read"); p != testPolicy {
+		t.Errorf("policy is %q, expected %q", p, testPolicy)
+	}
+	controllertest.AssertFinalizer(t, instance, minioPolicyFinalizer, false)
+	controllertest.AssertConflict(t, instance.Status.Conditions, "NotOwned")
+
+	// Deleting the MinioPolicy in conflict doesn't remove the policy
+	now := metav1.Now()
+	instance.DeletionTimestamp = &now
+	controllertest.Update(t, r.client, instance)
+	reconcileOK(t, r, other)
+	if _, ok := server.GetPolicy("mybucket-read"); !ok {
+		t.Error("policy removed by a MinioPolicy not owning it")
+	}
+}
+
+func TestReconcileReservedName(t *testing.T) {
+	for _, name := range []string{"readwrite", miniov1alpha1.GeneratedPolicyPrefix + "myUsername"} {
+		t.Run(name, func(t *testing.T) {
+			reserved := newMinioPolicy("default")
+			reserved.Spec.Name = name
+			reserved.Spec.Adopt = true
+			r, server := newTestReconciler(t, controllertest.NewMinioServer(), reserved)
+			if err := server.AddCannedPolicy(name, `{"Version":"2012-10-17","Statement":[]}`); err != nil {
+				t.Fatalf("server.AddCannedPolicy: %v", err)
+			}
+			server.ResetCalls()
+
+			instance := reconcileOK(t, r, reserved)
+
+			controllertest.AssertCallCount(t, server, "AddCannedPolicy", 0)
+			controllertest.AssertFinalizer(t, instance, minioPolicyFinalizer, false)
+			controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionReady, corev1.ConditionFalse, "Conflict")
+		})
+	}
+}
+
+func TestReconcileAdopt(t *testing.T) {
+	existing := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:*"],"Resource":["arn:aws:s3:::*"]}]}`
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), newMinioPolicy("default"))
+	if err := server.AddCannedPolicy("mybucket-read", existing); err != nil {
+		t.Fatalf("server.AddCannedPolicy: %v", err)
+	}
+
+	// A canned policy created outside of the operator is not replaced unless adopted
+	instance := reconcileOK(t, r, newMinioPolicy("default"))
+	if p, _ := server.GetPolicy("mybucket-read"); p != existing {
+		t.Errorf("policy is %q, expected the existing %q", p, existing)
+	}
+	controllertest.AssertFinalizer(t, instance, minioPolicyFinalizer, false)
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionConflict, corev1.ConditionTrue, "NotOwned")
+
+	instance.Spec.Adopt = true
+	controllertest.Update(t, r.client, instance)
+	instance = reconcileOK(t, r, instance)
+	if p, _ := server.GetPolicy("mybucket-read"); p != testPolicy {
+		t.Errorf("policy is %q, expected %q", p, testPolicy)
+	}
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionConflict, corev1.ConditionFalse, "Owned")
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionReady, corev1.ConditionTrue, "Reconciled")
+}
+
+func TestReconcileDeleteInUse(t *testing.T) {
+	user := &miniov1alpha1.MinioUser{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "user"},
+		Spec:       miniov1alpha1.MinioUserSpec{Server: "test", AccessKey: "myUsername", Policies: []string{"mybucket-read"}},
+	}
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), newMinioPolicy("default"), user)
+	instance := reconcileOK(t, r, newMinioPolicy("default"))
+
+	now := metav1.Now()
+	instance.DeletionTimestamp = &now
+	controllertest.Update(t, r.client, instance)
+	result, err := r.Reconcile(requestFor(instance))
+	if err != nil {
+		t.Fatalf("r.Reconcile: %v", err)
+	}
+
+	// The policy is kept while a MinioUser uses it
+	if _, ok := server.GetPolicy("mybucket-read"); !ok {
+		t.Error("policy removed while used by a MinioUser")
+	}
+	if result.RequeueAfter != policyInUseRequeueDelay {
+		t.Errorf("requeue after %v, expected %v", result.RequeueAfter, policyInUseRequeueDelay)
+	}
+	instance = reconcileOK(t, r, instance)
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionReady, corev1.ConditionFalse, "InUse")
+
+	if err := r.client.Delete(context.TODO(), user); err != nil {
+		t.Fatalf("r.client.Delete: %v", err)
+	}
+	instance = reconcileOK(t, r, instance)
+	if _, ok := server.GetPolicy("mybucket-read"); ok {
+		t.Error("unused policy not removed")
+	}
+	controllertest.AssertFinalizer(t, instance, minioPolicyFinalizer, false)
+}
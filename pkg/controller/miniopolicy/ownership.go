@@ -0,0 +1,72 @@
+package miniopolicy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/utils"
+)
+
+// checkOwnership return why a MinioPolicy can't manage its canned policy, empty if it can.
+// Canned policies can't be tagged, a canned policy is owned by the MinioPolicy holding the finalizer.
+// Built-in Minio policies and generated policies of MinioUser are never owned by a MinioPolicy, and an
+// existing canned policy is only managed when adopted.
+func (r *ReconcileMinioPolicy) checkOwnership(ctx context.Context, reqLogger logr.Logger, instance *miniov1alpha1.MinioPolicy, exists bool) (string, error) {
+	if miniov1alpha1.IsBuiltinPolicy(instance.Spec.Name) {
+		return fmt.Sprintf("Policy %s is a built-in Minio policy", instance.Spec.Name), nil
+	}
+	if strings.HasPrefix(instance.Spec.Name, miniov1alpha1.GeneratedPolicyPrefix) {
+		return fmt.Sprintf("Policy names starting with %s are reserved to MinioUser", miniov1alpha1.GeneratedPolicyPrefix), nil
+	}
+
+	policies := &miniov1alpha1.MinioPolicyList{}
+	if err := r.client.List(ctx, policies, client.MatchingField(nameIndexField, policyKey(instance.Spec.Server, instance.Spec.Name))); err != nil {
+		return "", fmt.Errorf("r.client.List: %w", err)
+	}
+	for _, item := range policies.Items {
+		if item.UID != instance.UID && item.Spec.Server == instance.Spec.Server && item.Spec.Name == instance.Spec.Name && utils.Contains(item.GetFinalizers(), minioPolicyFinalizer) {
+			return fmt.Sprintf("Policy is owned by MinioPolicy %s/%s", item.Namespace, item.Name), nil
+		}
+	}
+
+	if !exists {
+		return "", nil
+	}
+	if !instance.ShouldAdopt() {
+		return fmt.Sprintf("Policy already exists, set adopt or the %s annotation to manage it", miniov1alpha1.AdoptAnnotation), nil
+	}
+	reqLogger.Info("Adopt existing canned policy")
+	return "", nil
+}
+
+// usedBy return the MinioUser and MinioGroup using the canned policy of a MinioPolicy, as "kind namespace/name"
+func (r *ReconcileMinioPolicy) usedBy(ctx context.Context, instance *miniov1alpha1.MinioPolicy) ([]string, error) {
+	key := policyKey(instance.Spec.Server, instance.Spec.Name)
+	usedBy := []string{}
+
+	users := &miniov1alpha1.MinioUserList{}
+	if err := r.client.List(ctx, users, client.MatchingField(userPoliciesIndexField, key)); err != nil {
+		return nil, fmt.Errorf("r.client.List: %w", err)
+	}
+	for _, item := range users.Items {
+		if item.Spec.Server == instance.Spec.Server && utils.Contains(item.Spec.Policies, instance.Spec.Name) {
+			usedBy = append(usedBy, fmt.Sprintf("MinioUser %s/%s", item.Namespace, item.Name))
+		}
+	}
+
+	groups := &miniov1alpha1.MinioGroupList{}
+	if err := r.client.List(ctx, groups, client.MatchingField(groupPolicyIndexField, key)); err != nil {
+		return nil, fmt.Errorf("r.client.List: %w", err)
+	}
+	for _, item := range groups.Items {
+		if item.Spec.Server == instance.Spec.Server && item.Spec.Policy == instance.Spec.Name {
+			usedBy = append(usedBy, fmt.Sprintf("MinioGroup %s/%s", item.Namespace, item.Name))
+		}
+	}
+	return usedBy, nil
+}
@@ -0,0 +1,190 @@
+package minioserver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/minioclient"
+)
+
+var log = logf.Log.WithName("controller_minioserver")
+
+// probeInterval is the time between two health checks of a MinioServer
+const probeInterval = time.Minute
+
+// Add creates a new MinioServer Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager, minioClients minioclient.Clients) error {
+	return add(mgr, newReconciler(mgr, minioClients))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, minioClients minioclient.Clients) reconcile.Reconciler {
+	return &ReconcileMinioServer{
+		client:       mgr.GetClient(),
+		scheme:       mgr.GetScheme(),
+		recorder:     mgr.GetEventRecorderFor("minioserver-controller"),
+		minioClients: minioClients,
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("minioserver-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return fmt.Errorf("controller.New: %w", err)
+	}
+
+	// Watch for changes to primary resource MinioServer, ignoring status updates done by this controller
+	err = c.Watch(&source.Kind{Type: &miniov1alpha1.MinioServer{}}, &handler.EnqueueRequestForObject{}, predicate.GenerationChangedPredicate{})
+	if err != nil {
+		return fmt.Errorf("c.Watch: %w", err)
+	}
+
+	// Watch for changes to Secrets used by a MinioServer, to probe it again with new credentials
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: secretToRequests(mgr.GetClient()),
+	})
+	if err != nil {
+		return fmt.Errorf("c.Watch: %w", err)
+	}
+
+	return nil
+}
+
+// secretToRequests map a Secret to all MinioServer using it as credentials
+func secretToRequests(c client.Client) handler.ToRequestsFunc {
+	return func(a handler.MapObject) []reconcile.Request {
+		servers, err := minioclient.ServersUsingSecret(context.TODO(), c, a.Meta.GetNamespace(), a.Meta.GetName())
+		if err != nil {
+			log.Error(err, "minioclient.ServersUsingSecret")
+			return nil
+		}
+		requests := []reconcile.Request{}
+		for _, server := range servers {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: server}})
+		}
+		return requests
+	}
+}
+
+// blank assignment to verify that ReconcileMinioServer implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileMinioServer{}
+
+// ReconcileMinioServer reconciles a MinioServer object
+type ReconcileMinioServer struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client       client.Client
+	scheme       *runtime.Scheme
+	recorder     record.EventRecorder
+	minioClients minioclient.Clients
+}
+
+// Reconcile check the health of a MinioServer and record it in its status.
+// The request is requeued to check health periodically.
+func (r *ReconcileMinioServer) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Name", request.Name)
+	reqLogger.Info("Reconciling MinioServer")
+
+	// Fetch the MinioServer instance
+	instance := &miniov1alpha1.MinioServer{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// The MinioServer was deleted, its cached clients are not needed anymore
+			r.minioClients.Forget(request.Name)
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, fmt.Errorf("r.client.Get: %w", err)
+	}
+
+	wasOnline := instance.Status.Online
+	neverProbed := instance.Status.LastProbeTime == nil
+
+	status, probeErr := r.probe(context.TODO(), instance)
+	now := metav1.Now()
+	status.LastProbeTime = &now
+	if probeErr != nil {
+		reqLogger.Info("Minio server is offline", "error", probeErr.Error())
+		status.LastError = probeErr.Error()
+	} else {
+		reqLogger.Info("Minio server is online")
+	}
+	instance.Status = status
+
+	if status.Online && (!wasOnline || neverProbed) {
+		r.recorder.Event(instance, corev1.EventTypeNormal, "Online", "Minio server is online")
+	} else if !status.Online && (wasOnline || neverProbed) {
+		r.recorder.Event(instance, corev1.EventTypeWarning, "Offline", fmt.Sprintf("Minio server is offline: %s", status.LastError))
+	}
+
+	if err := r.client.Status().Update(context.TODO(), instance); err != nil {
+		return reconcile.Result{}, fmt.Errorf("r.client.Status().Update: %w", err)
+	}
+
+	return reconcile.Result{RequeueAfter: probeInterval}, nil
+}
+
+// probe check the liveness of a MinioServer and fetch its informations with an authenticated admin call
+func (r *ReconcileMinioServer) probe(ctx context.Context, instance *miniov1alpha1.MinioServer) (miniov1alpha1.MinioServerStatus, error) {
+	status := miniov1alpha1.MinioServerStatus{}
+
+	if err := r.minioClients.CheckLiveness(ctx, instance); err != nil {
+		return status, fmt.Errorf("r.minioClients.CheckLiveness: %w", err)
+	}
+
+	minioAdminClient, err := r.minioClients.ServerAdmin(ctx, instance)
+	if err != nil {
+		return status, fmt.Errorf("r.minioClients.ServerAdmin: %w", err)
+	}
+
+	info, err := minioAdminClient.ServerInfo()
+	if err != nil {
+		return status, fmt.Errorf("minioAdminClient.ServerInfo: %w", err)
+	}
+
+	status.Online = true
+	status.Region = info.Region
+	status.Nodes = len(info.Servers)
+	for _, server := range info.Servers {
+		if len(status.Version) == 0 {
+			status.Version = server.Version
+		}
+		status.Drives += len(server.Disks)
+	}
+	return status, nil
+}
+
+// DependentsPredicate filter MinioServer events relevant to resources depending on it:
+// all events except updates changing only the status, unless the server goes online or offline
+var DependentsPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if e.MetaOld.GetGeneration() != e.MetaNew.GetGeneration() {
+			return true
+		}
+		oldServer, okOld := e.ObjectOld.(*miniov1alpha1.MinioServer)
+		newServer, okNew := e.ObjectNew.(*miniov1alpha1.MinioServer)
+		return !okOld || !okNew || oldServer.Status.Online != newServer.Status.Online
+	},
+}
@@ -0,0 +1,135 @@
+package miniouser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+// writeConnectionSecret create or update the connection Secret of a MinioUser
+func (r *ReconcileMinioUser) writeConnectionSecret(ctx context.Context, instance *miniov1alpha1.MinioUser, minioServer *miniov1alpha1.MinioServer, secretKey string) error {
+	ref := instance.Spec.WriteConnectionSecretToRef
+	if ref == nil {
+		return nil
+	}
+
+	data, err := connectionSecretData(ref, minioServer, instance.Spec.AccessKey, secretKey)
+	if err != nil {
+		return fmt.Errorf("connectionSecretData: %w", err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: instance.Namespace,
+			Name:      ref.Name,
+		},
+	}
+	result, err := controllerutil.CreateOrUpdate(ctx, r.client, secret, func() error {
+		// Checked before the update, SetControllerReference takes over Secrets without controller
+		if secret.ResourceVersion != "" && !metav1.IsControlledBy(secret, instance) {
+			return fmt.Errorf("secret %s/%s is not controlled by the MinioUser", secret.Namespace, secret.Name)
+		}
+		secret.Type = corev1.SecretTypeOpaque
+		secret.Data = data
+		return controllerutil.SetControllerReference(instance, secret, r.scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("controllerutil.CreateOrUpdate: %w", err)
+	}
+	if result != controllerutil.OperationResultNone {
+		log.Info("Connection secret written", "Request.Namespace", instance.Namespace, "Request.Name", instance.Name, "Secret.Name", ref.Name, "Result", result)
+	}
+	return nil
+}
+
+// connectionSecretData return the content of a connection Secret in all requested formats
+func connectionSecretData(ref *miniov1alpha1.ConnectionSecret, minioServer *miniov1alpha1.MinioServer, accessKey, secretKey string) (map[string][]byte, error) {
+	formats := ref.Formats
+	if len(formats) == 0 {
+		formats = []miniov1alpha1.ConnectionSecretFormat{miniov1alpha1.ConnectionSecretFormatPlain}
+	}
+
+	data := map[string][]byte{}
+	for _, format := range formats {
+		switch format {
+		case miniov1alpha1.ConnectionSecretFormatPlain:
+			data["endpoint"] = []byte(minioServer.Spec.Hostname)
+			data["port"] = []byte(strconv.Itoa(minioServer.Spec.Port))
+			data["ssl"] = []byte(strconv.FormatBool(minioServer.Spec.SSL))
+			data["accessKey"] = []byte(accessKey)
+			data["secretKey"] = []byte(secretKey)
+			if len(ref.Bucket) != 0 {
+				data["bucket"] = []byte(ref.Bucket)
+			}
+		case miniov1alpha1.ConnectionSecretFormatAWS:
+			data["AWS_ENDPOINT_URL"] = []byte(minioServer.Spec.GetEndpointURL())
+			data["AWS_ACCESS_KEY_ID"] = []byte(accessKey)
+			data["AWS_SECRET_ACCESS_KEY"] = []byte(secretKey)
+		case miniov1alpha1.ConnectionSecretFormatS3cmd:
+			data[".s3cfg"] = s3cmdConfig(minioServer, accessKey, secretKey)
+		case miniov1alpha1.ConnectionSecretFormatMc:
+			config, err := mcConfig(minioServer, accessKey, secretKey)
+			if err != nil {
+				return nil, fmt.Errorf("mcConfig: %w", err)
+			}
+			data["config.json"] = config
+		default:
+			return nil, fmt.Errorf("unknown connection secret format %q", format)
+		}
+	}
+	return data, nil
+}
+
+// s3cmdConfig return a s3cmd configuration file
+func s3cmdConfig(minioServer *miniov1alpha1.MinioServer, accessKey, secretKey string) []byte {
+	useHTTPS := "False"
+	if minioServer.Spec.SSL {
+		useHTTPS = "True"
+	}
+
+	var b strings.Builder
+	fmt.Fprintln(&b, "[default]")
+	fmt.Fprintf(&b, "access_key = %s\n", accessKey)
+	fmt.Fprintf(&b, "secret_key = %s\n", secretKey)
+	fmt.Fprintf(&b, "host_base = %s\n", minioServer.Spec.GetHostname())
+	fmt.Fprintf(&b, "host_bucket = %s\n", minioServer.Spec.GetHostname())
+	fmt.Fprintf(&b, "use_https = %s\n", useHTTPS)
+	return []byte(b.String())
+}
+
+// mcHost is a host in a mc configuration file
+type mcHost struct {
+	URL       string `json:"url"`
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey"`
+	API       string `json:"api"`
+	Lookup    string `json:"lookup"`
+}
+
+// mcConfig return a mc configuration file, with the MinioServer name as alias
+func mcConfig(minioServer *miniov1alpha1.MinioServer, accessKey, secretKey string) ([]byte, error) {
+	config := struct {
+		Version string            `json:"version"`
+		Hosts   map[string]mcHost `json:"hosts"`
+	}{
+		Version: "9",
+		Hosts: map[string]mcHost{
+			minioServer.Name: {
+				URL:       minioServer.Spec.GetEndpointURL(),
+				AccessKey: accessKey,
+				SecretKey: secretKey,
+				API:       "S3v4",
+				Lookup:    "auto",
+			},
+		},
+	}
+	return json.MarshalIndent(config, "", "\t")
+}
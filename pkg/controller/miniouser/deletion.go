@@ -0,0 +1,52 @@
+package miniouser
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/minio/minio/pkg/madmin"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/minioclient"
+)
+
+// deleteUser remove, keep or disable a Minio user and its generated canned policy according to the MinioUser deletion policy
+func (r *ReconcileMinioUser) deleteUser(reqLogger logr.Logger, instance *miniov1alpha1.MinioUser, minioAdminClient minioclient.IAMAdmin, isUserExists, isPolicyExists bool, userPolicyName string) error {
+	switch instance.Spec.DeletionPolicy {
+	case miniov1alpha1.UserDeletionRetain:
+		reqLogger.Info("Deletion policy is Retain, keep Minio user")
+		return nil
+	case miniov1alpha1.UserDeletionDisable:
+		if !isUserExists {
+			reqLogger.Info("Minio user already removed")
+			return nil
+		}
+		reqLogger.Info("Deletion policy is Disable, disable Minio user")
+		if err := minioAdminClient.SetUserStatus(instance.Spec.AccessKey, madmin.AccountDisabled); err != nil {
+			return fmt.Errorf("minioAdminClient.SetUserStatus: %w", err)
+		}
+		reqLogger.Info("Minio user disabled")
+		return nil
+	}
+
+	if isUserExists {
+		reqLogger.Info("Remove Minio user")
+		if err := minioAdminClient.RemoveUser(instance.Spec.AccessKey); err != nil {
+			return fmt.Errorf("minioAdminClient.RemoveUser: %w", err)
+		}
+		reqLogger.Info("Minio user removed")
+	} else {
+		reqLogger.Info("Minio user already removed")
+	}
+
+	if isPolicyExists {
+		reqLogger.Info("Delete Minio canned policy")
+		if err := minioAdminClient.RemoveCannedPolicy(userPolicyName); err != nil {
+			return fmt.Errorf("minioAdminClient.RemoveCannedPolicy: %w", err)
+		}
+		reqLogger.Info("Minio policy removed")
+	} else {
+		reqLogger.Info("Minio policy already removed")
+	}
+	return nil
+}
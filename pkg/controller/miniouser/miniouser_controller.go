@@ -3,11 +3,17 @@ package miniouser
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/minio/minio/pkg/madmin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -18,22 +24,39 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/controller/minioserver"
+	"github.com/robotinfra/minio-resources-operator/pkg/metrics"
+	"github.com/robotinfra/minio-resources-operator/pkg/minioclient"
+	"github.com/robotinfra/minio-resources-operator/pkg/policy"
 	"github.com/robotinfra/minio-resources-operator/pkg/utils"
 )
 
 var log = logf.Log.WithName("controller_miniouser")
 
-const minioUserFinalizer = "finalizer.user.minio.robotinfra.com"
+const (
+	minioUserFinalizer = "finalizer.user.minio.robotinfra.com"
+
+	// serverOfflineRequeueDelay is the delay before retrying when the MinioServer is offline
+	serverOfflineRequeueDelay = time.Minute
+
+	// serverIndexField is the field indexing MinioUser by MinioServer name
+	serverIndexField = "spec.server"
+)
 
 // Add creates a new MinioUser Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+func Add(mgr manager.Manager, minioClients minioclient.Clients) error {
+	return add(mgr, newReconciler(mgr, minioClients))
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileMinioUser{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+func newReconciler(mgr manager.Manager, minioClients minioclient.Clients) reconcile.Reconciler {
+	return &ReconcileMinioUser{
+		client:       mgr.GetClient(),
+		scheme:       mgr.GetScheme(),
+		recorder:     mgr.GetEventRecorderFor("miniouser-controller"),
+		minioClients: minioClients,
+	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -44,15 +67,133 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return fmt.Errorf("controller.New: %w", err)
 	}
 
+	// Index MinioUser by MinioServer, to find them when a MinioServer changes
+	err = mgr.GetFieldIndexer().IndexField(&miniov1alpha1.MinioUser{}, serverIndexField, func(o runtime.Object) []string {
+		return []string{o.(*miniov1alpha1.MinioUser).Spec.Server}
+	})
+	if err != nil {
+		return fmt.Errorf("mgr.GetFieldIndexer().IndexField: %w", err)
+	}
+
 	// Watch for changes to primary resource MinioUser
 	err = c.Watch(&source.Kind{Type: &miniov1alpha1.MinioUser{}}, &handler.EnqueueRequestForObject{})
 	if err != nil {
 		return fmt.Errorf("c.Watch: %w", err)
 	}
 
+	// Watch for changes to MinioPolicy and requeue all MinioUser using them
+	err = c.Watch(&source.Kind{Type: &miniov1alpha1.MinioPolicy{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: policyToRequests(mgr.GetClient()),
+	})
+	if err != nil {
+		return fmt.Errorf("c.Watch: %w", err)
+	}
+
+	// Watch for changes to MinioServer credentials and MinioUser secret key Secrets
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: secretToRequests(mgr.GetClient()),
+	})
+	if err != nil {
+		return fmt.Errorf("c.Watch: %w", err)
+	}
+
+	// Watch for changes to secondary resource Secrets and requeue the owner MinioUser
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &miniov1alpha1.MinioUser{},
+	})
+	if err != nil {
+		return fmt.Errorf("c.Watch: %w", err)
+	}
+
+	// Watch for changes to MinioServer and requeue all its MinioUser
+	err = c.Watch(&source.Kind{Type: &miniov1alpha1.MinioServer{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			return serversToRequests(mgr.GetClient(), []string{a.Meta.GetName()})
+		}),
+	}, minioserver.DependentsPredicate)
+	if err != nil {
+		return fmt.Errorf("c.Watch: %w", err)
+	}
+
 	return nil
 }
 
+// secretToRequests map a Secret to all MinioUser of the MinioServer using it as credentials,
+// and all MinioUser reading their secret key from it or writing to it
+func secretToRequests(c client.Client) handler.ToRequestsFunc {
+	return func(a handler.MapObject) []reconcile.Request {
+		servers, err := minioclient.ServersUsingSecret(context.TODO(), c, a.Meta.GetNamespace(), a.Meta.GetName())
+		if err != nil {
+			log.Error(err, "minioclient.ServersUsingSecret")
+			return nil
+		}
+		requests := serversToRequests(c, servers)
+
+		users := &miniov1alpha1.MinioUserList{}
+		if err := c.List(context.TODO(), users, client.InNamespace(a.Meta.GetNamespace())); err != nil {
+			log.Error(err, "c.List")
+			return requests
+		}
+		for _, item := range users.Items {
+			isSecretKeyRef := item.Spec.SecretKeyRef != nil && item.Spec.SecretKeyRef.Name == a.Meta.GetName()
+			if isSecretKeyRef || utils.Contains(writtenSecrets(&item), a.Meta.GetName()) {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+					Namespace: item.Namespace,
+					Name:      item.Name,
+				}})
+			}
+		}
+		return requests
+	}
+}
+
+// policyToRequests map a MinioPolicy to all MinioUser of its MinioServer using it
+func policyToRequests(c client.Client) handler.ToRequestsFunc {
+	return func(a handler.MapObject) []reconcile.Request {
+		minioPolicy, ok := a.Object.(*miniov1alpha1.MinioPolicy)
+		if !ok {
+			return nil
+		}
+
+		users := &miniov1alpha1.MinioUserList{}
+		if err := c.List(context.TODO(), users, client.MatchingField(serverIndexField, minioPolicy.Spec.Server)); err != nil {
+			log.Error(err, "c.List")
+			return nil
+		}
+
+		requests := []reconcile.Request{}
+		for _, item := range users.Items {
+			if utils.Contains(item.Spec.Policies, minioPolicy.Spec.Name) {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+					Namespace: item.Namespace,
+					Name:      item.Name,
+				}})
+			}
+		}
+		return requests
+	}
+}
+
+// serversToRequests return a request for all MinioUser of a list of MinioServer
+func serversToRequests(c client.Client, servers []string) []reconcile.Request {
+	requests := []reconcile.Request{}
+	for _, server := range servers {
+		users := &miniov1alpha1.MinioUserList{}
+		if err := c.List(context.TODO(), users, client.MatchingField(serverIndexField, server)); err != nil {
+			log.Error(err, "c.List")
+			continue
+		}
+		for _, item := range users.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: item.Namespace,
+				Name:      item.Name,
+			}})
+		}
+	}
+	return requests
+}
+
 // blank assignment to verify that ReconcileMinioUser implements reconcile.Reconciler
 var _ reconcile.Reconciler = &ReconcileMinioUser{}
 
@@ -60,8 +201,10 @@ var _ reconcile.Reconciler = &ReconcileMinioUser{}
 type ReconcileMinioUser struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
-	client client.Client
-	scheme *runtime.Scheme
+	client       client.Client
+	scheme       *runtime.Scheme
+	recorder     record.EventRecorder
+	minioClients minioclient.Clients
 }
 
 // Reconcile reads that state of the cluster for a MinioUser object and makes changes based on the state read
@@ -83,29 +226,71 @@ func (r *ReconcileMinioUser) Reconcile(request reconcile.Request) (reconcile.Res
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, fmt.Errorf("r.client.Get: %w", err)
 	}
+	originalStatus := instance.Status.DeepCopy()
+
+	result, err := r.reconcileUser(reqLogger, instance)
+	if err != nil {
+		// A failure retried with the same error is only reported once
+		if !originalStatus.Conditions.IsReconcileFailedWith(err) {
+			r.recorder.Event(instance, corev1.EventTypeWarning, "ReconcileFailed", err.Error())
+		}
+		instance.Status.Conditions.SetReconcileFailed(err)
+		instance.Status.LastError = err.Error()
+	} else {
+		instance.Status.LastError = ""
+	}
+
+	if instance.GetDeletionTimestamp() != nil && !utils.Contains(instance.GetFinalizers(), minioUserFinalizer) {
+		// Object is being deleted, there is no status to update
+		return result, err
+	}
+
+	if !equality.Semantic.DeepEqual(originalStatus, &instance.Status) {
+		reqLogger.Info("Update status")
+		if updateErr := r.client.Status().Update(context.TODO(), instance); updateErr != nil && err == nil {
+			return reconcile.Result{}, fmt.Errorf("r.client.Status().Update: %w", updateErr)
+		}
+	}
 
+	return result, err
+}
+
+// reconcileUser converge the Minio user to the MinioUser spec, and record observations in its status
+func (r *ReconcileMinioUser) reconcileUser(reqLogger logr.Logger, instance *miniov1alpha1.MinioUser) (reconcile.Result, error) {
 	minioServer := &miniov1alpha1.MinioServer{}
 	if err := r.client.Get(context.TODO(), types.NamespacedName{
-		Name:      instance.Spec.Server,
+		Name: instance.Spec.Server,
 	}, minioServer); err != nil {
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionServerReachable, corev1.ConditionFalse, "ServerNotFound", err.Error())
 		return reconcile.Result{}, fmt.Errorf("r.client.Get: %w", err)
 	}
 
-	// doc is https://github.com/minio/minio/tree/master/pkg/madmin
-	minioAdminClient, err := madmin.New(minioServer.Spec.GetHostname(), minioServer.Spec.AccessKey, minioServer.Spec.SecretKey, minioServer.Spec.SSL)
+	if minioServer.IsOffline() {
+		reqLogger.Info("Minio server is offline, wait for it to be online")
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionServerReachable, corev1.ConditionFalse, "ServerOffline", minioServer.Status.LastError)
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionReady, corev1.ConditionFalse, "ServerOffline", fmt.Sprintf("MinioServer %s is offline", minioServer.Name))
+		return reconcile.Result{RequeueAfter: serverOfflineRequeueDelay}, nil
+	}
+
+	minioAdminClient, err := r.minioClients.IAMAdmin(context.TODO(), minioServer)
 	if err != nil {
-		return reconcile.Result{}, fmt.Errorf("madmin.New: %w", err)
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionServerReachable, corev1.ConditionFalse, "ClientFailed", err.Error())
+		return reconcile.Result{}, fmt.Errorf("r.minioClients.IAMAdmin: %w", err)
 	}
 
 	reqLogger.Info("List all Minio users")
 	allUsers, err := minioAdminClient.ListUsers()
 	if err != nil {
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionServerReachable, corev1.ConditionFalse, "RequestFailed", err.Error())
 		return reconcile.Result{}, fmt.Errorf("minioAdminClient.ListUsers: %w", err)
 	}
+	instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionServerReachable, corev1.ConditionTrue, "Reachable", "")
 	reqLogger.Info("Got user list")
 	existingUser, isUserExists := allUsers[instance.Spec.AccessKey]
+	instance.Status.PolicyName = existingUser.PolicyName
+	instance.Status.AccountStatus = string(existingUser.Status)
 
-	userPolicyName := fmt.Sprintf("_generator_%s", instance.Spec.AccessKey)
+	userPolicyName := instance.GetGeneratedPolicyName()
 	reqLogger = reqLogger.WithValues("Minio.Policy", userPolicyName)
 
 	reqLogger.Info("List all Minio policies")
@@ -119,29 +304,28 @@ func (r *ReconcileMinioUser) Reconcile(request reconcile.Request) (reconcile.Res
 
 	finalizerPresent := utils.Contains(instance.GetFinalizers(), minioUserFinalizer)
 
+	// An existing user is only managed once owned, the finalizer is added after this check
+	if instance.GetDeletionTimestamp() == nil && isUserExists && !finalizerPresent {
+		conflict, err := r.checkOwnership(context.TODO(), reqLogger, instance)
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("r.checkOwnership: %w", err)
+		}
+		if conflict != "" {
+			reqLogger.Info("User is not owned by the MinioUser", "reason", conflict)
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionConflict, corev1.ConditionTrue, "NotOwned", conflict)
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionReady, corev1.ConditionFalse, "Conflict", conflict)
+			return reconcile.Result{}, nil
+		}
+	}
+
 	if instance.GetDeletionTimestamp() != nil {
 		if finalizerPresent {
 			// Run finalization logic for. If the
 			// finalization logic fails, don't remove the finalizer so
 			// that we can retry during the next reconciliation.
-			if isUserExists {
-				reqLogger.Info("Instance marked for deletion, remove Minio user")
-				if err = minioAdminClient.RemoveUser(instance.Spec.AccessKey); err != nil {
-					return reconcile.Result{}, fmt.Errorf("minioAdminClient.RemoveUser: %w", err)
-				}
-				reqLogger.Info("Minio user removed")
-			} else {
-				reqLogger.Info("Minio user already removed")
-			}
-
-			if isPolicyExists {
-				reqLogger.Info("Delete Minio canned policy")
-				if err = minioAdminClient.RemoveCannedPolicy(userPolicyName); err != nil {
-					return reconcile.Result{}, fmt.Errorf("minioAdminClient.RemoveCannedPolicy: %w", err)
-				}
-				reqLogger.Info("Minio policy removed")
-			} else {
-				reqLogger.Info("Minio policy already removed")
+			reqLogger.Info("Instance marked for deletion, delete Minio user", "deletionPolicy", instance.Spec.DeletionPolicy)
+			if err = r.deleteUser(reqLogger, instance, minioAdminClient, isUserExists, isPolicyExists, userPolicyName); err != nil {
+				return reconcile.Result{}, fmt.Errorf("r.deleteUser: %w", err)
 			}
 
 			// Remove minioUserFinalizer. Once all finalizers have been
@@ -158,21 +342,47 @@ func (r *ReconcileMinioUser) Reconcile(request reconcile.Request) (reconcile.Res
 		return reconcile.Result{}, nil
 	}
 
+	// Secrets are only written once controlled by the MinioUser, to not overwrite or read other Secrets
+	for _, name := range writtenSecrets(instance) {
+		conflict, err := r.checkSecretOwnership(context.TODO(), instance, name)
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("r.checkSecretOwnership: %w", err)
+		}
+		if conflict != "" {
+			reqLogger.Info("Secret is not owned by the MinioUser", "reason", conflict)
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionConflict, corev1.ConditionTrue, "SecretNotOwned", conflict)
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionReady, corev1.ConditionFalse, "Conflict", conflict)
+			return reconcile.Result{}, nil
+		}
+	}
+
 	if err := controllerutil.SetControllerReference(minioServer, instance, r.scheme); err != nil {
 		return reconcile.Result{}, fmt.Errorf("controllerutil.SetControllerReference: %w", err)
 	}
 
 	if !finalizerPresent {
 		reqLogger.Info("No finalizer, add it")
+		// Update replaces instance with the stored object, keep the status observed by this reconcile
+		status := instance.Status.DeepCopy()
 		instance.SetFinalizers(append(instance.GetFinalizers(), minioUserFinalizer))
 		if err = r.client.Update(context.TODO(), instance); err != nil {
 			return reconcile.Result{}, fmt.Errorf("r.client.Update: %w", err)
 		}
+		instance.Status = *status
 		reqLogger.Info("Finalizer added")
+		r.recorder.Eventf(instance, corev1.EventTypeNormal, "FinalizerAdded", "Finalizer %s added", minioUserFinalizer)
+	}
+	instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionConflict, corev1.ConditionFalse, "Owned", "")
+
+	attachedPolicyName, generatedPolicy, err := userPolicy(instance, userPolicyName, allPolicies)
+	if err != nil {
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionPolicyApplied, corev1.ConditionFalse, "InvalidPolicy", err.Error())
+		return reconcile.Result{}, fmt.Errorf("userPolicy: %w", err)
 	}
 
-	isUserPolicy := len(instance.Spec.Policy) != 0
+	isUserPolicy := len(generatedPolicy) != 0
 	needCreate := true
+	policyUpdated := false
 	if isPolicyExists {
 		if !isUserPolicy {
 			reqLogger.Info("Policy exists but unused, remove")
@@ -183,13 +393,20 @@ func (r *ReconcileMinioUser) Reconcile(request reconcile.Request) (reconcile.Res
 			reqLogger.Info("Unused policy removed")
 		} else {
 			reqLogger.Info("Policy already exists, check if update needed")
-			if existingPolicy != instance.Spec.Policy {
-				reqLogger.Info("Policy key is different, recreate")
+			isPolicyEqual, err := policy.Equal(existingPolicy, generatedPolicy)
+			if err != nil {
+				instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionPolicyApplied, corev1.ConditionFalse, "InvalidPolicy", err.Error())
+				return reconcile.Result{}, fmt.Errorf("policy.Equal: %w", err)
+			}
+			if !isPolicyEqual {
+				diff, _ := policy.Diff(existingPolicy, generatedPolicy)
+				reqLogger.Info("Policy is different, recreate", "diff", diff)
 				reqLogger.Info("Delete existing policy")
 				if err = minioAdminClient.RemoveCannedPolicy(userPolicyName); err != nil {
 					return reconcile.Result{}, fmt.Errorf("minioAdminClient.RemoveCannedPolicy: %w", err)
 				}
 				reqLogger.Info("Existing policy deleted")
+				policyUpdated = true
 			} else {
 				needCreate = false
 				reqLogger.Info("Policy is correct state")
@@ -199,33 +416,94 @@ func (r *ReconcileMinioUser) Reconcile(request reconcile.Request) (reconcile.Res
 
 	if needCreate && isUserPolicy {
 		reqLogger.Info("Create new policy")
-		if err = minioAdminClient.AddCannedPolicy(userPolicyName, instance.Spec.Policy); err != nil {
+		if err = minioAdminClient.AddCannedPolicy(userPolicyName, generatedPolicy); err != nil {
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionPolicyApplied, corev1.ConditionFalse, "AddPolicyFailed", err.Error())
 			return reconcile.Result{}, fmt.Errorf("minioAdminClient.AddCannedPolicy: %w", err)
 		}
 		reqLogger.Info("New policy created")
+		if policyUpdated {
+			r.recorder.Eventf(instance, corev1.EventTypeNormal, "PolicyUpdated", "Policy %s updated", userPolicyName)
+			// The spec was already applied, the policy was changed outside of the operator
+			if instance.Status.ObservedGeneration == instance.GetGeneration() {
+				metrics.DriftCorrected(instance.Spec.Server, "MinioUser", "policy")
+			}
+		}
+	}
+
+	secretKey, err := r.getSecretKey(context.TODO(), instance)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("r.getSecretKey: %w", err)
 	}
 
 	if !isUserExists {
 		reqLogger.Info("Create user")
-		if err = minioAdminClient.AddUser(instance.Spec.AccessKey, instance.Spec.SecretKey); err != nil {
+		if err = minioAdminClient.AddUser(instance.Spec.AccessKey, secretKey); err != nil {
 			return reconcile.Result{}, fmt.Errorf("minioAdminClient.AddUser: %w", err)
 		}
 		reqLogger.Info("User created")
+		r.recorder.Eventf(instance, corev1.EventTypeNormal, "UserCreated", "User %s created", instance.Spec.AccessKey)
 	}
 
-	if isUserPolicy && (existingUser.PolicyName != userPolicyName || needCreate) {
-		reqLogger.Info("Set user policy")
-		if err = minioAdminClient.SetPolicy(userPolicyName, instance.Spec.AccessKey, false); err != nil {
+	isAttachedPolicy := len(attachedPolicyName) != 0
+	if isAttachedPolicy && (existingUser.PolicyName != attachedPolicyName || (isUserPolicy && needCreate)) {
+		reqLogger.Info("Set user policy", "Policy", attachedPolicyName)
+		if err = minioAdminClient.SetPolicy(attachedPolicyName, instance.Spec.AccessKey, false); err != nil {
+			instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionPolicyApplied, corev1.ConditionFalse, "SetPolicyFailed", err.Error())
 			return reconcile.Result{}, fmt.Errorf("minioAdminClient.SetPolicy: %w", err)
 		}
 		reqLogger.Info("User policy set")
+		if isUserExists && existingUser.PolicyName != attachedPolicyName {
+			r.recorder.Eventf(instance, corev1.EventTypeNormal, "PolicyUpdated", "Policy %s attached", attachedPolicyName)
+		}
+	}
+	if isAttachedPolicy {
+		instance.Status.PolicyName = attachedPolicyName
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionPolicyApplied, corev1.ConditionTrue, "PolicyApplied", "")
+	} else {
+		instance.Status.PolicyName = ""
+		instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionPolicyApplied, corev1.ConditionFalse, "NoPolicy", "No policy in spec")
 	}
 
 	reqLogger.Info("Set user secret key")
-	if err = minioAdminClient.SetUser(instance.Spec.AccessKey, instance.Spec.SecretKey, madmin.AccountEnabled); err != nil {
+	if err = minioAdminClient.SetUser(instance.Spec.AccessKey, secretKey, madmin.AccountEnabled); err != nil {
 		return reconcile.Result{}, fmt.Errorf("minioAdminClient.SetUser: %w", err)
 	}
-	reqLogger.Info("Secret key set, reconcilied")
+	instance.Status.AccountStatus = string(madmin.AccountEnabled)
+	reqLogger.Info("Secret key set")
+
+	if err = r.writeConnectionSecret(context.TODO(), instance, minioServer, secretKey); err != nil {
+		return reconcile.Result{}, fmt.Errorf("r.writeConnectionSecret: %w", err)
+	}
+
+	instance.Status.ObservedGeneration = instance.GetGeneration()
+	instance.Status.Conditions.SetCondition(miniov1alpha1.ConditionReady, corev1.ConditionTrue, "Reconciled", "")
+	reqLogger.Info("MinioUser reconcilied")
 
 	return reconcile.Result{}, nil
 }
+
+// userPolicy return the names of the canned policies to attach to a MinioUser, comma separated, and the content
+// of its generated policy, empty if not needed. The inline policy is merged with the policy rules and presets
+// in the generated policy. Referenced policies are attached as is, followed by the generated policy.
+func userPolicy(instance *miniov1alpha1.MinioUser, generatedPolicyName string, allPolicies map[string][]byte) (string, string, error) {
+	rendered, err := policy.RenderCanned(instance.Spec.PolicyRules, instance.Spec.PolicyPresets)
+	if err != nil {
+		return "", "", fmt.Errorf("policy.RenderCanned: %w", err)
+	}
+	inlinePolicy, err := policy.Combine(instance.Spec.Policy, rendered)
+	if err != nil {
+		return "", "", fmt.Errorf("policy.Combine: %w", err)
+	}
+
+	names := []string{}
+	for _, name := range instance.Spec.Policies {
+		if _, ok := allPolicies[name]; !ok {
+			return "", "", fmt.Errorf("policy %q not found", name)
+		}
+		names = append(names, name)
+	}
+	if len(inlinePolicy) != 0 {
+		names = append(names, generatedPolicyName)
+	}
+	return strings.Join(names, ","), inlinePolicy, nil
+}
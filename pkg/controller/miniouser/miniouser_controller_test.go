@@ -0,0 +1,420 @@
+package miniouser
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/minio/minio/pkg/madmin"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/controller/internal/controllertest"
+	miniofake "github.com/robotinfra/minio-resources-operator/pkg/minioclient/fake"
+)
+
+const (
+	testPolicy   = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:*"],"Resource":["arn:aws:s3:::mybucket","arn:aws:s3:::mybucket/*"]}]}`
+	otherPolicy  = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:GetObject"],"Resource":["arn:aws:s3:::mybucket/*"]}]}`
+	testPassword = "mySecurePassword"
+	policyName   = "_generator_myUsername"
+)
+
+var request = reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "user"}}
+
+// newTestReconciler return a reconciler using a fake Kubernetes client with objects, and a fake Minio server
+func newTestReconciler(t *testing.T, objs ...runtime.Object) (*ReconcileMinioUser, *miniofake.Server) {
+	c, s := controllertest.NewClient(t, objs...)
+	server := miniofake.NewServer()
+	return &ReconcileMinioUser{
+		client:       c,
+		scheme:       s,
+		recorder:     record.NewFakeRecorder(100),
+		minioClients: server,
+	}, server
+}
+
+func newMinioUser() *miniov1alpha1.MinioUser {
+	return &miniov1alpha1.MinioUser{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "user", UID: "user-uid"},
+		Spec: miniov1alpha1.MinioUserSpec{
+			Server:    "test",
+			AccessKey: "myUsername",
+			SecretKey: testPassword,
+			Policy:    testPolicy,
+		},
+	}
+}
+
+// getMinioUser return the MinioUser of the request
+func getMinioUser(t *testing.T, r *ReconcileMinioUser) *miniov1alpha1.MinioUser {
+	t.Helper()
+	instance := &miniov1alpha1.MinioUser{}
+	controllertest.Get(t, r.client, request, instance)
+	return instance
+}
+
+func TestReconcileCreate(t *testing.T) {
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), newMinioUser())
+
+	controllertest.ReconcileOK(t, r, request)
+
+	user, ok := server.GetUser("myUsername")
+	if !ok {
+		t.Fatal("user not created")
+	}
+	if user.SecretKey != testPassword || user.Status != madmin.AccountEnabled || user.PolicyName != policyName {
+		t.Errorf("user is %+v, expected enabled with its secret key and policy %s", user, policyName)
+	}
+	if policy, _ := server.GetPolicy(policyName); policy != testPolicy {
+		t.Errorf("policy is %q, expected %q", policy, testPolicy)
+	}
+
+	instance := getMinioUser(t, r)
+	controllertest.AssertFinalizer(t, instance, minioUserFinalizer, true)
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionReady, corev1.ConditionTrue, "Reconciled")
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionPolicyApplied, corev1.ConditionTrue, "PolicyApplied")
+	if instance.Status.PolicyName != policyName || instance.Status.AccountStatus != string(madmin.AccountEnabled) {
+		t.Errorf("status is %+v, expected policy %s and enabled account", instance.Status, policyName)
+	}
+}
+
+func TestReconcileCreateKeepsStatus(t *testing.T) {
+	r, _ := newTestReconciler(t, controllertest.NewMinioServer(), newMinioUser())
+	r.client = controllertest.StatusSubresourceClient{Client: r.client}
+
+	controllertest.ReconcileOK(t, r, request)
+
+	// Conditions set before the finalizer is added are not lost
+	instance := getMinioUser(t, r)
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionServerReachable, corev1.ConditionTrue, "Reachable")
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionReady, corev1.ConditionTrue, "Reconciled")
+}
+
+func TestReconcileSteadyState(t *testing.T) {
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), newMinioUser())
+	controllertest.ReconcileOK(t, r, request)
+	server.ResetCalls()
+
+	controllertest.ReconcileOK(t, r, request)
+
+	for _, method := range []string{"AddUser", "AddCannedPolicy", "RemoveCannedPolicy", "SetPolicy"} {
+		controllertest.AssertCallCount(t, server, method, 0)
+	}
+}
+
+func TestReconcileUpdate(t *testing.T) {
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), newMinioUser())
+	controllertest.ReconcileOK(t, r, request)
+
+	instance := getMinioUser(t, r)
+	instance.Spec.Policy = otherPolicy
+	controllertest.Update(t, r.client, instance)
+	controllertest.ReconcileOK(t, r, request)
+
+	if policy, _ := server.GetPolicy(policyName); policy != otherPolicy {
+		t.Errorf("policy is %q, expected %q", policy, otherPolicy)
+	}
+	if user, _ := server.GetUser("myUsername"); user.PolicyName != policyName {
+		t.Errorf("user policy is %q, expected %q", user.PolicyName, policyName)
+	}
+}
+
+func TestReconcileDrift(t *testing.T) {
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), newMinioUser())
+	controllertest.ReconcileOK(t, r, request)
+
+	if err := server.AddCannedPolicy(policyName, otherPolicy); err != nil {
+		t.Fatalf("server.AddCannedPolicy: %v", err)
+	}
+	if err := server.SetUser("myUsername", "anotherPassword", madmin.AccountDisabled); err != nil {
+		t.Fatalf("server.SetUser: %v", err)
+	}
+	controllertest.ReconcileOK(t, r, request)
+
+	if policy, _ := server.GetPolicy(policyName); policy != testPolicy {
+		t.Errorf("policy is %q, expected %q", policy, testPolicy)
+	}
+	if user, _ := server.GetUser("myUsername"); user.SecretKey != testPassword || user.Status != madmin.AccountEnabled {
+		t.Errorf("user is %+v, expected enabled with its secret key", user)
+	}
+}
+
+func TestReconcilePolicies(t *testing.T) {
+	user := newMinioUser()
+	user.Spec.Policy = ""
+	user.Spec.Policies = []string{"shared"}
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), user)
+	if err := server.AddCannedPolicy("shared", testPolicy); err != nil {
+		t.Fatalf("server.AddCannedPolicy: %v", err)
+	}
+
+	controllertest.ReconcileOK(t, r, request)
+
+	if u, _ := server.GetUser("myUsername"); u.PolicyName != "shared" {
+		t.Errorf("user policy is %q, expected shared", u.PolicyName)
+	}
+	if _, ok := server.GetPolicy(policyName); ok {
+		t.Errorf("policy %s generated for a single referenced policy", policyName)
+	}
+}
+
+func TestReconcilePoliciesWithInline(t *testing.T) {
+	user := newMinioUser()
+	user.Spec.Policies = []string{"shared", "other"}
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), user)
+	for name, document := range map[string]string{"shared": testPolicy, "other": otherPolicy} {
+		if err := server.AddCannedPolicy(name, document); err != nil {
+			t.Fatalf("server.AddCannedPolicy: %v", err)
+		}
+	}
+
+	controllertest.ReconcileOK(t, r, request)
+
+	// Referenced policies are attached as is, the generated policy only holds the inline policy
+	expected := "shared,other," + policyName
+	if u, _ := server.GetUser("myUsername"); u.PolicyName != expected {
+		t.Errorf("user policy is %q, expected %q", u.PolicyName, expected)
+	}
+	if p, _ := server.GetPolicy(policyName); p != testPolicy {
+		t.Errorf("generated policy is %q, expected the inline policy %q", p, testPolicy)
+	}
+	if instance := getMinioUser(t, r); instance.Status.PolicyName != expected {
+		t.Errorf("status policy name is %q, expected %q", instance.Status.PolicyName, expected)
+	}
+
+	server.ResetCalls()
+	controllertest.ReconcileOK(t, r, request)
+	controllertest.AssertCallCount(t, server, "SetPolicy", 0)
+}
+
+func TestReconcileConflict(t *testing.T) {
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), newMinioUser())
+	if err := server.AddUser("myUsername", "anotherPassword"); err != nil {
+		t.Fatalf("server.AddUser: %v", err)
+	}
+	server.ResetCalls()
+
+	controllertest.ReconcileOK(t, r, request)
+
+	controllertest.AssertCallCount(t, server, "SetUser", 0)
+	instance := getMinioUser(t, r)
+	controllertest.AssertFinalizer(t, instance, minioUserFinalizer, false)
+	controllertest.AssertConflict(t, instance.Status.Conditions, "NotOwned")
+
+	instance.Spec.Adopt = true
+	controllertest.Update(t, r.client, instance)
+	controllertest.ReconcileOK(t, r, request)
+
+	controllertest.AssertCondition(t, getMinioUser(t, r).Status.Conditions, miniov1alpha1.ConditionConflict, corev1.ConditionFalse, "Owned")
+	if user, _ := server.GetUser("myUsername"); user.SecretKey != testPassword {
+		t.Error("secret key of the adopted user not set")
+	}
+}
+
+func TestReconcileConnectionSecretConflict(t *testing.T) {
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "credentials"},
+		Data:       map[string][]byte{"secretKey": []byte(testPassword)},
+	}
+	user := newMinioUser()
+	user.Spec.SecretKey = ""
+	user.Spec.SecretKeyRef = &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "credentials"}, Key: "secretKey"}
+	user.Spec.WriteConnectionSecretToRef = &miniov1alpha1.ConnectionSecret{Name: "credentials"}
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), user, credentials)
+
+	controllertest.ReconcileOK(t, r, request)
+
+	if _, ok := server.GetUser("myUsername"); ok {
+		t.Error("user created with a connection Secret not owned")
+	}
+	secret := &corev1.Secret{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "credentials"}, secret); err != nil {
+		t.Fatalf("r.client.Get: %v", err)
+	}
+	if len(secret.Data) != 1 || string(secret.Data["secretKey"]) != testPassword || len(secret.OwnerReferences) != 0 {
+		t.Errorf("secret is %+v, expected it unchanged", secret)
+	}
+	instance := getMinioUser(t, r)
+	controllertest.AssertConflict(t, instance.Status.Conditions, "SecretNotOwned")
+
+	instance.Spec.WriteConnectionSecretToRef.Name = "connection"
+	controllertest.Update(t, r.client, instance)
+	controllertest.ReconcileOK(t, r, request)
+
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "connection"}, secret); err != nil {
+		t.Fatalf("r.client.Get: %v", err)
+	}
+	if string(secret.Data["accessKey"]) != "myUsername" || !metav1.IsControlledBy(secret, instance) {
+		t.Errorf("secret is %+v, expected the connection informations controlled by the MinioUser", secret)
+	}
+	controllertest.AssertCondition(t, getMinioUser(t, r).Status.Conditions, miniov1alpha1.ConditionReady, corev1.ConditionTrue, "Reconciled")
+}
+
+func TestReconcileGeneratedSecretConflict(t *testing.T) {
+	existing := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "user-minio-user"},
+		Data:       map[string][]byte{miniov1alpha1.GeneratedSecretKeyKey: []byte("anotherPassword")},
+	}
+	user := newMinioUser()
+	user.Spec.SecretKey = ""
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), user, existing)
+
+	controllertest.ReconcileOK(t, r, request)
+
+	if _, ok := server.GetUser("myUsername"); ok {
+		t.Error("user created with the secret key of a Secret not owned")
+	}
+	controllertest.AssertCondition(t, getMinioUser(t, r).Status.Conditions, miniov1alpha1.ConditionConflict, corev1.ConditionTrue, "SecretNotOwned")
+
+	if err := r.client.Delete(context.TODO(), existing); err != nil {
+		t.Fatalf("r.client.Delete: %v", err)
+	}
+	controllertest.ReconcileOK(t, r, request)
+
+	secret := &corev1.Secret{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "user-minio-user"}, secret); err != nil {
+		t.Fatalf("r.client.Get: %v", err)
+	}
+	instance := getMinioUser(t, r)
+	if !metav1.IsControlledBy(secret, instance) {
+		t.Error("generated Secret not controlled by the MinioUser")
+	}
+	if user, _ := server.GetUser("myUsername"); user.SecretKey != string(secret.Data[miniov1alpha1.GeneratedSecretKeyKey]) {
+		t.Error("user not created with the generated secret key")
+	}
+	controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionReady, corev1.ConditionTrue, "Reconciled")
+}
+
+func TestReconcileErrors(t *testing.T) {
+	tests := []struct {
+		method string
+		reason string
+	}{
+		{method: "IAMAdmin", reason: "ClientFailed"},
+		{method: "ListUsers", reason: "RequestFailed"},
+		{method: "AddCannedPolicy", reason: ""},
+		{method: "AddUser", reason: ""},
+		{method: "SetPolicy", reason: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			r, server := newTestReconciler(t, controllertest.NewMinioServer(), newMinioUser())
+			injected := errors.New("injected error")
+			server.SetError(tt.method, injected)
+
+			if _, err := r.Reconcile(request); !errors.Is(err, injected) {
+				t.Fatalf("r.Reconcile returned %v, expected the injected error", err)
+			}
+			instance := getMinioUser(t, r)
+			controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionReady, corev1.ConditionFalse, "ReconcileFailed")
+			if instance.Status.LastError == "" {
+				t.Error("last error not recorded")
+			}
+			if tt.reason != "" {
+				controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionServerReachable, corev1.ConditionFalse, tt.reason)
+			}
+
+			server.SetError(tt.method, nil)
+			controllertest.ReconcileOK(t, r, request)
+			instance = getMinioUser(t, r)
+			controllertest.AssertCondition(t, instance.Status.Conditions, miniov1alpha1.ConditionReady, corev1.ConditionTrue, "Reconciled")
+			if instance.Status.LastError != "" {
+				t.Errorf("last error %q not cleared", instance.Status.LastError)
+			}
+		})
+	}
+}
+
+func TestReconcileDelete(t *testing.T) {
+	tests := []struct {
+		deletionPolicy miniov1alpha1.UserDeletionPolicy
+		userExists     bool
+		userStatus     madmin.AccountStatus
+		policyExists   bool
+	}{
+		{deletionPolicy: "", userExists: false, policyExists: false},
+		{deletionPolicy: miniov1alpha1.UserDeletionDelete, userExists: false, policyExists: false},
+		{deletionPolicy: miniov1alpha1.UserDeletionRetain, userExists: true, userStatus: madmin.AccountEnabled, policyExists: true},
+		{deletionPolicy: miniov1alpha1.UserDeletionDisable, userExists: true, userStatus: madmin.AccountDisabled, policyExists: true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.deletionPolicy), func(t *testing.T) {
+			r, server := newTestReconciler(t, controllertest.NewMinioServer(), newMinioUser())
+			controllertest.ReconcileOK(t, r, request)
+
+			instance := getMinioUser(t, r)
+			now := metav1.Now()
+			instance.SetDeletionTimestamp(&now)
+			instance.Spec.DeletionPolicy = tt.deletionPolicy
+			controllertest.Update(t, r.client, instance)
+			controllertest.ReconcileOK(t, r, request)
+
+			user, userExists := server.GetUser("myUsername")
+			if userExists != tt.userExists || (userExists && user.Status != tt.userStatus) {
+				t.Errorf("user exists %v with status %q, expected %v with status %q", userExists, user.Status, tt.userExists, tt.userStatus)
+			}
+			if _, policyExists := server.GetPolicy(policyName); policyExists != tt.policyExists {
+				t.Errorf("policy exists %v, expected %v", policyExists, tt.policyExists)
+			}
+			instance = getMinioUser(t, r)
+			controllertest.AssertFinalizer(t, instance, minioUserFinalizer, false)
+		})
+	}
+}
+
+func TestReconcileDeleteError(t *testing.T) {
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), newMinioUser())
+	controllertest.ReconcileOK(t, r, request)
+
+	instance := getMinioUser(t, r)
+	now := metav1.Now()
+	instance.SetDeletionTimestamp(&now)
+	controllertest.Update(t, r.client, instance)
+	injected := errors.New("injected error")
+	server.SetError("RemoveUser", injected)
+
+	if _, err := r.Reconcile(request); !errors.Is(err, injected) {
+		t.Fatalf("r.Reconcile returned %v, expected the injected error", err)
+	}
+	if _, ok := server.GetUser("myUsername"); !ok {
+		t.Error("user removed while RemoveUser failed")
+	}
+	instance = getMinioUser(t, r)
+	controllertest.AssertFinalizer(t, instance, minioUserFinalizer, true)
+}
+
+func TestReconcileEvents(t *testing.T) {
+	r, server := newTestReconciler(t, controllertest.NewMinioServer(), newMinioUser())
+
+	controllertest.ReconcileOK(t, r, request)
+	controllertest.AssertEvents(t, r.recorder, "Normal FinalizerAdded", "Normal UserCreated")
+
+	controllertest.ReconcileOK(t, r, request)
+	controllertest.AssertEvents(t, r.recorder)
+
+	instance := getMinioUser(t, r)
+	instance.Spec.Policy = otherPolicy
+	controllertest.Update(t, r.client, instance)
+	controllertest.ReconcileOK(t, r, request)
+	controllertest.AssertEvents(t, r.recorder, "Normal PolicyUpdated")
+
+	server.SetError("ListUsers", errors.New("injected error"))
+	for i := 0; i < 3; i++ {
+		if _, err := r.Reconcile(request); err == nil {
+			t.Fatal("r.Reconcile succeeded with an injected error")
+		}
+	}
+	controllertest.AssertEvents(t, r.recorder, "Warning ReconcileFailed")
+
+	server.SetError("ListUsers", errors.New("another error"))
+	if _, err := r.Reconcile(request); err == nil {
+		t.Fatal("r.Reconcile succeeded with an injected error")
+	}
+	controllertest.AssertEvents(t, r.recorder, "Warning ReconcileFailed")
+}
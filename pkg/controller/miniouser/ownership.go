@@ -0,0 +1,65 @@
+package miniouser
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/utils"
+)
+
+// checkOwnership return why a MinioUser can't manage an existing Minio user, empty if it can.
+// Minio users can't be tagged, a Minio user is owned by the MinioUser holding the finalizer.
+func (r *ReconcileMinioUser) checkOwnership(ctx context.Context, reqLogger logr.Logger, instance *miniov1alpha1.MinioUser) (string, error) {
+	users := &miniov1alpha1.MinioUserList{}
+	if err := r.client.List(ctx, users, client.MatchingField(serverIndexField, instance.Spec.Server)); err != nil {
+		return "", fmt.Errorf("r.client.List: %w", err)
+	}
+	for _, item := range users.Items {
+		if item.UID != instance.UID && item.Spec.AccessKey == instance.Spec.AccessKey && utils.Contains(item.GetFinalizers(), minioUserFinalizer) {
+			return fmt.Sprintf("User is owned by MinioUser %s/%s", item.Namespace, item.Name), nil
+		}
+	}
+
+	if !instance.ShouldAdopt() {
+		return fmt.Sprintf("User already exists, set adopt or the %s annotation to manage it", miniov1alpha1.AdoptAnnotation), nil
+	}
+	reqLogger.Info("Adopt existing user")
+	return "", nil
+}
+
+// writtenSecrets return the names of the Secrets written by a MinioUser: its connection Secret, and the
+// Secret of its generated secret key
+func writtenSecrets(instance *miniov1alpha1.MinioUser) []string {
+	names := []string{}
+	if ref := instance.Spec.WriteConnectionSecretToRef; ref != nil {
+		names = append(names, ref.Name)
+	}
+	if instance.Spec.SecretKey == "" && instance.Spec.SecretKeyRef == nil {
+		names = append(names, instance.GetGeneratedSecretName())
+	}
+	return names
+}
+
+// checkSecretOwnership return why a MinioUser can't write a Secret, empty if the Secret doesn't exist or is
+// controlled by the MinioUser
+func (r *ReconcileMinioUser) checkSecretOwnership(ctx context.Context, instance *miniov1alpha1.MinioUser, name string) (string, error) {
+	secret := &corev1.Secret{}
+	if err := r.client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: name}, secret); err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("r.client.Get: %w", err)
+	}
+	if !metav1.IsControlledBy(secret, instance) {
+		return fmt.Sprintf("Secret %s already exists and is not controlled by the MinioUser", name), nil
+	}
+	return "", nil
+}
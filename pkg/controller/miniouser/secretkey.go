@@ -0,0 +1,105 @@
+package miniouser
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+// generatedSecretKeyBytes is the number of random bytes of a generated secret key,
+// 30 bytes are encoded to 40 characters, the maximum length allowed by Minio
+const generatedSecretKeyBytes = 30
+
+// getSecretKey return the secret key of a MinioUser, from its spec, a referenced Secret or a generated Secret
+func (r *ReconcileMinioUser) getSecretKey(ctx context.Context, instance *miniov1alpha1.MinioUser) (string, error) {
+	if len(instance.Spec.SecretKey) != 0 {
+		return instance.Spec.SecretKey, nil
+	}
+
+	if ref := instance.Spec.SecretKeyRef; ref != nil {
+		secret := &corev1.Secret{}
+		if err := r.client.Get(ctx, types.NamespacedName{
+			Namespace: instance.Namespace,
+			Name:      ref.Name,
+		}, secret); err != nil {
+			return "", fmt.Errorf("r.client.Get: %w", err)
+		}
+		secretKey, ok := secret.Data[ref.Key]
+		if !ok {
+			return "", fmt.Errorf("key %q not found in secret %s/%s", ref.Key, instance.Namespace, ref.Name)
+		}
+		return string(secretKey), nil
+	}
+
+	return r.getGeneratedSecretKey(ctx, instance)
+}
+
+// getGeneratedSecretKey return the secret key stored in the generated Secret of a MinioUser,
+// and create the Secret with a random secret key if it doesn't exist yet
+func (r *ReconcileMinioUser) getGeneratedSecretKey(ctx context.Context, instance *miniov1alpha1.MinioUser) (string, error) {
+	reqLogger := log.WithValues("Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+
+	secret := &corev1.Secret{}
+	err := r.client.Get(ctx, types.NamespacedName{
+		Namespace: instance.Namespace,
+		Name:      instance.GetGeneratedSecretName(),
+	}, secret)
+	if err == nil {
+		if !metav1.IsControlledBy(secret, instance) {
+			return "", fmt.Errorf("secret %s/%s is not controlled by the MinioUser", secret.Namespace, secret.Name)
+		}
+		secretKey, ok := secret.Data[miniov1alpha1.GeneratedSecretKeyKey]
+		if !ok {
+			return "", fmt.Errorf("key %q not found in secret %s/%s", miniov1alpha1.GeneratedSecretKeyKey, secret.Namespace, secret.Name)
+		}
+		return string(secretKey), nil
+	}
+	if !errors.IsNotFound(err) {
+		return "", fmt.Errorf("r.client.Get: %w", err)
+	}
+
+	reqLogger.Info("No secret key, generate it")
+	secretKey, err := generateSecretKey()
+	if err != nil {
+		return "", fmt.Errorf("generateSecretKey: %w", err)
+	}
+
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: instance.Namespace,
+			Name:      instance.GetGeneratedSecretName(),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			miniov1alpha1.GeneratedSecretKeyKey: []byte(secretKey),
+		},
+	}
+	// Secret is garbage collected with the MinioUser
+	if err := controllerutil.SetControllerReference(instance, secret, r.scheme); err != nil {
+		return "", fmt.Errorf("controllerutil.SetControllerReference: %w", err)
+	}
+	if err := r.client.Create(ctx, secret); err != nil {
+		return "", fmt.Errorf("r.client.Create: %w", err)
+	}
+	reqLogger.Info("Generated secret key stored", "Secret.Name", secret.Name)
+
+	return secretKey, nil
+}
+
+// generateSecretKey return a random secret key
+func generateSecretKey() (string, error) {
+	b := make([]byte, generatedSecretKeyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("rand.Read: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
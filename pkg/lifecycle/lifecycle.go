@@ -0,0 +1,227 @@
+// Package lifecycle handle S3 bucket lifecycle configuration documents
+package lifecycle
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+// dateFormat is the format of expiration dates
+const dateFormat = "2006-01-02T15:04:05Z"
+
+// Rule status
+const (
+	statusEnabled  = "Enabled"
+	statusDisabled = "Disabled"
+)
+
+// configuration is the S3 lifecycle configuration document
+type configuration struct {
+	XMLName xml.Name `xml:"LifecycleConfiguration"`
+	Rules   []rule   `xml:"Rule"`
+}
+
+type rule struct {
+	ID     string  `xml:"ID,omitempty"`
+	Status string  `xml:"Status"`
+	Filter *filter `xml:"Filter,omitempty"`
+	// Prefix is deprecated in favor of Filter, but may be returned by servers
+	Prefix                         *string                         `xml:"Prefix,omitempty"`
+	Expiration                     *expiration                     `xml:"Expiration,omitempty"`
+	NoncurrentVersionExpiration    *noncurrentVersionExpiration    `xml:"NoncurrentVersionExpiration,omitempty"`
+	AbortIncompleteMultipartUpload *abortIncompleteMultipartUpload `xml:"AbortIncompleteMultipartUpload,omitempty"`
+}
+
+type filter struct {
+	Prefix *string `xml:"Prefix,omitempty"`
+	Tag    *tag    `xml:"Tag,omitempty"`
+	And    *and    `xml:"And,omitempty"`
+}
+
+type and struct {
+	Prefix string `xml:"Prefix,omitempty"`
+	Tags   []tag  `xml:"Tag,omitempty"`
+}
+
+type tag struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+type expiration struct {
+	Days int32  `xml:"Days,omitempty"`
+	Date string `xml:"Date,omitempty"`
+}
+
+type noncurrentVersionExpiration struct {
+	NoncurrentDays int32 `xml:"NoncurrentDays"`
+}
+
+type abortIncompleteMultipartUpload struct {
+	DaysAfterInitiation int32 `xml:"DaysAfterInitiation"`
+}
+
+// Render return the lifecycle configuration document of lifecycle rules, empty without rules
+func Render(rules []miniov1alpha1.LifecycleRule) (string, error) {
+	if len(rules) == 0 {
+		return "", nil
+	}
+
+	config := configuration{}
+	ids := map[string]bool{}
+	for _, r := range rules {
+		if r.ID == "" {
+			return "", errors.New("lifecycle rule without id")
+		}
+		if ids[r.ID] {
+			return "", fmt.Errorf("duplicate lifecycle rule id %s", r.ID)
+		}
+		ids[r.ID] = true
+
+		if r.Expiration == nil && r.NoncurrentVersionExpirationDays == 0 && r.AbortIncompleteMultipartUploadDays == 0 {
+			return "", fmt.Errorf("lifecycle rule %s has no action", r.ID)
+		}
+
+		item := rule{
+			ID:     r.ID,
+			Status: statusEnabled,
+			Filter: renderFilter(r.Prefix, r.Tags),
+		}
+		if r.Disabled {
+			item.Status = statusDisabled
+		}
+		if r.Expiration != nil {
+			if (r.Expiration.Days == 0) == (r.Expiration.Date == nil) {
+				return "", fmt.Errorf("lifecycle rule %s expiration must have either days or date", r.ID)
+			}
+			item.Expiration = &expiration{Days: r.Expiration.Days}
+			if r.Expiration.Date != nil {
+				item.Expiration.Date = r.Expiration.Date.UTC().Format(dateFormat)
+			}
+		}
+		if r.NoncurrentVersionExpirationDays != 0 {
+			item.NoncurrentVersionExpiration = &noncurrentVersionExpiration{NoncurrentDays: r.NoncurrentVersionExpirationDays}
+		}
+		if r.AbortIncompleteMultipartUploadDays != 0 {
+			item.AbortIncompleteMultipartUpload = &abortIncompleteMultipartUpload{DaysAfterInitiation: r.AbortIncompleteMultipartUploadDays}
+		}
+		config.Rules = append(config.Rules, item)
+	}
+
+	document, err := xml.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("xml.Marshal: %w", err)
+	}
+	return string(document), nil
+}
+
+// renderFilter return the filter of a rule, using And only with several criteria
+func renderFilter(prefix string, tags map[string]string) *filter {
+	sortedTags := sortTags(tags)
+	switch {
+	case len(sortedTags) == 0:
+		return &filter{Prefix: &prefix}
+	case len(sortedTags) == 1 && prefix == "":
+		return &filter{Tag: &sortedTags[0]}
+	default:
+		return &filter{And: &and{Prefix: prefix, Tags: sortedTags}}
+	}
+}
+
+// sortTags return tags as a list sorted by key
+func sortTags(tags map[string]string) []tag {
+	list := []tag{}
+	for key, value := range tags {
+		list = append(list, tag{Key: key, Value: value})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list
+}
+
+// normalizedRule is a lifecycle rule independent of the document layout
+type normalizedRule struct {
+	ID                 string
+	Status             string
+	Prefix             string
+	Tags               map[string]string
+	ExpirationDays     int32
+	ExpirationDate     time.Time
+	NoncurrentDays     int32
+	AbortMultipartDays int32
+}
+
+// normalize parse a lifecycle configuration document into rules indexed by id
+func normalize(document string) (map[string]normalizedRule, error) {
+	rules := map[string]normalizedRule{}
+	if document == "" {
+		return rules, nil
+	}
+
+	config := configuration{}
+	if err := xml.Unmarshal([]byte(document), &config); err != nil {
+		return nil, fmt.Errorf("xml.Unmarshal: %w", err)
+	}
+
+	for _, r := range config.Rules {
+		item := normalizedRule{
+			ID:     r.ID,
+			Status: r.Status,
+			Tags:   map[string]string{},
+		}
+		if r.Prefix != nil {
+			item.Prefix = *r.Prefix
+		}
+		if r.Filter != nil {
+			if r.Filter.Prefix != nil {
+				item.Prefix = *r.Filter.Prefix
+			}
+			if r.Filter.Tag != nil {
+				item.Tags[r.Filter.Tag.Key] = r.Filter.Tag.Value
+			}
+			if r.Filter.And != nil {
+				item.Prefix = r.Filter.And.Prefix
+				for _, t := range r.Filter.And.Tags {
+					item.Tags[t.Key] = t.Value
+				}
+			}
+		}
+		if r.Expiration != nil {
+			item.ExpirationDays = r.Expiration.Days
+			if r.Expiration.Date != "" {
+				date, err := time.Parse(time.RFC3339, r.Expiration.Date)
+				if err != nil {
+					return nil, fmt.Errorf("time.Parse: %w", err)
+				}
+				item.ExpirationDate = date.UTC()
+			}
+		}
+		if r.NoncurrentVersionExpiration != nil {
+			item.NoncurrentDays = r.NoncurrentVersionExpiration.NoncurrentDays
+		}
+		if r.AbortIncompleteMultipartUpload != nil {
+			item.AbortMultipartDays = r.AbortIncompleteMultipartUpload.DaysAfterInitiation
+		}
+		rules[item.ID] = item
+	}
+	return rules, nil
+}
+
+// Equal return true if two lifecycle configuration documents have the same rules,
+// regardless of rules order and filter layout
+func Equal(a, b string) (bool, error) {
+	rulesA, err := normalize(a)
+	if err != nil {
+		return false, fmt.Errorf("normalize: %w", err)
+	}
+	rulesB, err := normalize(b)
+	if err != nil {
+		return false, fmt.Errorf("normalize: %w", err)
+	}
+	return reflect.DeepEqual(rulesA, rulesB), nil
+}
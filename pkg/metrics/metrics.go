@@ -0,0 +1,79 @@
+// Package metrics define the Prometheus metrics of the operator, served with the controller-runtime metrics.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// namespace is the prefix of the operator metrics names
+const namespace = "minio_operator"
+
+var (
+	// apiRequests count the Minio API requests, per MinioServer and operation
+	apiRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_requests_total",
+		Help:      "Number of Minio API requests, per MinioServer and operation.",
+	}, []string{"server", "operation"})
+
+	// apiRequestErrors count the failed Minio API requests, per MinioServer and operation
+	apiRequestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_request_errors_total",
+		Help:      "Number of failed Minio API requests, per MinioServer and operation.",
+	}, []string{"server", "operation"})
+
+	// apiRequestDuration observe the latency of Minio API requests, per MinioServer and operation
+	apiRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "api_request_duration_seconds",
+		Help:      "Latency of Minio API requests, per MinioServer and operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"server", "operation"})
+
+	// driftCorrections count the changes made outside of the operator and reverted, per MinioServer,
+	// resource kind and field
+	driftCorrections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "drift_corrections_total",
+		Help:      "Number of changes made on Minio servers outside of the operator and reverted, per MinioServer, kind and field.",
+	}, []string{"server", "kind", "field"})
+
+	// bucketSize is the size of the bucket of a MinioBucket
+	bucketSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "bucket_size_bytes",
+		Help:      "Total size of the objects of a MinioBucket, as last computed by the Minio server.",
+	}, []string{"namespace", "name", "server", "bucket"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(apiRequests, apiRequestErrors, apiRequestDuration, driftCorrections, bucketSize)
+}
+
+// ObserveRequest record a Minio API request to a MinioServer started at start, failed if err is not nil
+func ObserveRequest(server, operation string, start time.Time, err error) {
+	apiRequests.WithLabelValues(server, operation).Inc()
+	apiRequestDuration.WithLabelValues(server, operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		apiRequestErrors.WithLabelValues(server, operation).Inc()
+	}
+}
+
+// DriftCorrected record the correction of a field of a resource changed outside of the operator
+func DriftCorrected(server, kind, field string) {
+	driftCorrections.WithLabelValues(server, kind, field).Inc()
+}
+
+// SetBucketUsage record the size of the bucket of a MinioBucket
+func SetBucketUsage(namespace, name, server, bucket string, size int64) {
+	bucketSize.WithLabelValues(namespace, name, server, bucket).Set(float64(size))
+}
+
+// DeleteBucketUsage forget the usage of the bucket of a MinioBucket
+func DeleteBucketUsage(namespace, name, server, bucket string) {
+	bucketSize.DeleteLabelValues(namespace, name, server, bucket)
+}
@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+var log = logf.Log.WithName("metrics")
+
+var (
+	serverOnlineDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "server_online"),
+		"Whether a MinioServer is online, 1 when its last health check succeeded.",
+		[]string{"server"}, nil,
+	)
+	managedBucketsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "managed_buckets"),
+		"Number of MinioBucket, per MinioServer.",
+		[]string{"server"}, nil,
+	)
+	managedUsersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "managed_users"),
+		"Number of MinioUser, per MinioServer.",
+		[]string{"server"}, nil,
+	)
+	blockedDeletionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "blocked_deletions"),
+		"Number of MinioBucket and MinioUser being deleted, but kept by their finalizer, per MinioServer, kind and reason.",
+		[]string{"server", "kind", "reason"}, nil,
+	)
+)
+
+// resourcesCollector collect metrics of the MinioServer, MinioBucket and MinioUser in the cluster
+type resourcesCollector struct {
+	client client.Client
+}
+
+// RegisterResources register the metrics of resources read with c, usually the cached client of the manager
+func RegisterResources(c client.Client) error {
+	return metrics.Registry.Register(&resourcesCollector{client: c})
+}
+
+// Describe implements prometheus.Collector
+func (rc *resourcesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- serverOnlineDesc
+	ch <- managedBucketsDesc
+	ch <- managedUsersDesc
+	ch <- blockedDeletionsDesc
+}
+
+// Collect implements prometheus.Collector
+func (rc *resourcesCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx := context.TODO()
+
+	servers := &miniov1alpha1.MinioServerList{}
+	if err := rc.client.List(ctx, servers); err != nil {
+		log.Error(err, "rc.client.List")
+		return
+	}
+	buckets := map[string]int{}
+	users := map[string]int{}
+	for _, server := range servers.Items {
+		online := 0.0
+		if server.Status.Online {
+			online = 1
+		}
+		ch <- prometheus.MustNewConstMetric(serverOnlineDesc, prometheus.GaugeValue, online, server.Name)
+		buckets[server.Name] = 0
+		users[server.Name] = 0
+	}
+
+	// blocked deletions are keyed by server, kind and reason
+	blocked := map[[3]string]int{}
+
+	bucketList := &miniov1alpha1.MinioBucketList{}
+	if err := rc.client.List(ctx, bucketList); err != nil {
+		log.Error(err, "rc.client.List")
+		return
+	}
+	for _, bucket := range bucketList.Items {
+		buckets[bucket.Spec.Server]++
+		if bucket.GetDeletionTimestamp() != nil && len(bucket.GetFinalizers()) != 0 {
+			blocked[[3]string{bucket.Spec.Server, "MinioBucket", readyReason(bucket.Status.Conditions)}]++
+		}
+	}
+
+	userList := &miniov1alpha1.MinioUserList{}
+	if err := rc.client.List(ctx, userList); err != nil {
+		log.Error(err, "rc.client.List")
+		return
+	}
+	for _, user := range userList.Items {
+		users[user.Spec.Server]++
+		if user.GetDeletionTimestamp() != nil && len(user.GetFinalizers()) != 0 {
+			blocked[[3]string{user.Spec.Server, "MinioUser", readyReason(user.Status.Conditions)}]++
+		}
+	}
+
+	for server, count := range buckets {
+		ch <- prometheus.MustNewConstMetric(managedBucketsDesc, prometheus.GaugeValue, float64(count), server)
+	}
+	for server, count := range users {
+		ch <- prometheus.MustNewConstMetric(managedUsersDesc, prometheus.GaugeValue, float64(count), server)
+	}
+	for labels, count := range blocked {
+		ch <- prometheus.MustNewConstMetric(blockedDeletionsDesc, prometheus.GaugeValue, float64(count), labels[0], labels[1], labels[2])
+	}
+}
+
+// readyReason return the reason of the Ready condition, Unknown without it
+func readyReason(conditions miniov1alpha1.Conditions) string {
+	condition := conditions.GetCondition(miniov1alpha1.ConditionReady)
+	if condition == nil || condition.Reason == "" {
+		return "Unknown"
+	}
+	return condition.Reason
+}
@@ -0,0 +1,150 @@
+package minioclient
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/minio/minio-go"
+	"github.com/minio/minio/pkg/madmin"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+// Clients provide the clients managing buckets and IAM of MinioServers
+type Clients interface {
+	// BucketAdmin return a client managing the buckets of a MinioServer
+	BucketAdmin(ctx context.Context, server *miniov1alpha1.MinioServer) (BucketAdmin, error)
+	// IAMAdmin return a client managing the users, groups and policies of a MinioServer
+	IAMAdmin(ctx context.Context, server *miniov1alpha1.MinioServer) (IAMAdmin, error)
+	// ServerAdmin return a client fetching the informations and data usage of a MinioServer
+	ServerAdmin(ctx context.Context, server *miniov1alpha1.MinioServer) (ServerAdmin, error)
+	// CheckLiveness call the unauthenticated liveness endpoint of a MinioServer
+	CheckLiveness(ctx context.Context, server *miniov1alpha1.MinioServer) error
+	// Forget drop the clients of a deleted MinioServer
+	Forget(server string)
+}
+
+// BucketAdmin manage the buckets of a Minio server, and their objects.
+// Methods with a context are not implemented by minio-go, the S3 API is called directly.
+type BucketAdmin interface {
+	BucketExists(bucket string) (bool, error)
+	MakeBucket(bucket, location string) error
+	RemoveBucket(bucket string) error
+	ListBuckets() ([]minio.BucketInfo, error)
+
+	GetBucketPolicy(bucket string) (string, error)
+	SetBucketPolicy(bucket, policy string) error
+	GetBucketLifecycle(bucket string) (string, error)
+	SetBucketLifecycle(bucket, lifecycle string) error
+	GetBucketNotification(bucket string) (minio.BucketNotification, error)
+	SetBucketNotification(bucket string, notification minio.BucketNotification) error
+	RemoveAllBucketNotification(bucket string) error
+
+	ListObjectsV2(bucket, prefix string, recursive bool, doneCh <-chan struct{}) <-chan minio.ObjectInfo
+	RemoveObjects(bucket string, objectsCh <-chan string) <-chan minio.RemoveObjectError
+	ListIncompleteUploads(bucket, prefix string, recursive bool, doneCh <-chan struct{}) <-chan minio.ObjectMultipartInfo
+	RemoveIncompleteUpload(bucket, object string) error
+
+	GetBucketVersioning(ctx context.Context, bucket string) (string, error)
+	SetBucketVersioning(ctx context.Context, bucket, status string) error
+	ListObjectVersions(ctx context.Context, bucket string, maxKeys int) ([]ObjectVersion, error)
+	RemoveObjectVersion(ctx context.Context, bucket string, version ObjectVersion) error
+	GetBucketTags(ctx context.Context, bucket string) (map[string]string, error)
+	SetBucketTags(ctx context.Context, bucket string, tags map[string]string) error
+}
+
+// IAMAdmin manage the users, groups and canned policies of a Minio server
+type IAMAdmin interface {
+	ListUsers() (map[string]madmin.UserInfo, error)
+	AddUser(accessKey, secretKey string) error
+	SetUser(accessKey, secretKey string, status madmin.AccountStatus) error
+	SetUserStatus(accessKey string, status madmin.AccountStatus) error
+	RemoveUser(accessKey string) error
+
+	ListCannedPolicies() (map[string][]byte, error)
+	AddCannedPolicy(name, policy string) error
+	RemoveCannedPolicy(name string) error
+	SetPolicy(policyName, entityName string, isGroup bool) error
+
+	GetGroupDescription(group string) (*madmin.GroupDesc, error)
+	UpdateGroupMembers(members madmin.GroupAddRemove) error
+	SetGroupStatus(group string, status madmin.GroupStatus) error
+}
+
+// ServerAdmin fetch the informations and data usage of a Minio server
+type ServerAdmin interface {
+	ServerInfo() (madmin.InfoMessage, error)
+	DataUsageInfo() (madmin.DataUsageInfo, error)
+}
+
+// blank assignments to verify that Pool and its clients implement the interfaces
+var (
+	_ Clients     = &Pool{}
+	_ BucketAdmin = &bucketAdmin{}
+	_ IAMAdmin    = &madmin.AdminClient{}
+	_ ServerAdmin = &madmin.AdminClient{}
+)
+
+// BucketAdmin return a client managing the buckets of a MinioServer
+func (p *Pool) BucketAdmin(ctx context.Context, server *miniov1alpha1.MinioServer) (BucketAdmin, error) {
+	minioClient, err := p.Client(ctx, server)
+	if err != nil {
+		return nil, fmt.Errorf("p.Client: %w", err)
+	}
+	return &bucketAdmin{Client: minioClient, pool: p, server: server}, nil
+}
+
+// IAMAdmin return a client managing the users, groups and policies of a MinioServer
+func (p *Pool) IAMAdmin(ctx context.Context, server *miniov1alpha1.MinioServer) (IAMAdmin, error) {
+	adminClient, err := p.AdminClient(ctx, server)
+	if err != nil {
+		return nil, fmt.Errorf("p.AdminClient: %w", err)
+	}
+	return adminClient, nil
+}
+
+// ServerAdmin return a client fetching the informations and data usage of a MinioServer
+func (p *Pool) ServerAdmin(ctx context.Context, server *miniov1alpha1.MinioServer) (ServerAdmin, error) {
+	adminClient, err := p.AdminClient(ctx, server)
+	if err != nil {
+		return nil, fmt.Errorf("p.AdminClient: %w", err)
+	}
+	return adminClient, nil
+}
+
+// bucketAdmin is a minio-go client, completed with the S3 APIs it doesn't implement
+type bucketAdmin struct {
+	*minio.Client
+	pool   *Pool
+	server *miniov1alpha1.MinioServer
+}
+
+// GetBucketVersioning implements BucketAdmin
+func (b *bucketAdmin) GetBucketVersioning(ctx context.Context, bucket string) (string, error) {
+	return b.pool.GetBucketVersioning(ctx, b.server, bucket)
+}
+
+// SetBucketVersioning implements BucketAdmin
+func (b *bucketAdmin) SetBucketVersioning(ctx context.Context, bucket, status string) error {
+	return b.pool.SetBucketVersioning(ctx, b.server, bucket, status)
+}
+
+// ListObjectVersions implements BucketAdmin
+func (b *bucketAdmin) ListObjectVersions(ctx context.Context, bucket string, maxKeys int) ([]ObjectVersion, error) {
+	return b.pool.ListObjectVersions(ctx, b.server, bucket, maxKeys)
+}
+
+// RemoveObjectVersion implements BucketAdmin
+func (b *bucketAdmin) RemoveObjectVersion(ctx context.Context, bucket string, version ObjectVersion) error {
+	return b.pool.RemoveObjectVersion(ctx, b.server, bucket, version)
+}
+
+// GetBucketTags implements BucketAdmin
+func (b *bucketAdmin) GetBucketTags(ctx context.Context, bucket string) (map[string]string, error) {
+	return b.pool.GetBucketTags(ctx, b.server, bucket)
+}
+
+// SetBucketTags implements BucketAdmin
+func (b *bucketAdmin) SetBucketTags(ctx context.Context, bucket string, tags map[string]string) error {
+	return b.pool.SetBucketTags(ctx, b.server, bucket, tags)
+}
@@ -0,0 +1,105 @@
+// Package minioclient build Minio clients from MinioServer resources
+package minioclient
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+const (
+	defaultAccessKeyKey = "accessKey"
+	defaultSecretKeyKey = "secretKey"
+)
+
+// GetCredentials return the admin access key and secret key of a MinioServer.
+// They are read from the credentials Secret if referenced, from the deprecated inline fields otherwise.
+func GetCredentials(ctx context.Context, c client.Client, server *miniov1alpha1.MinioServer) (string, string, error) {
+	ref := server.Spec.CredentialsSecretRef
+	if ref == nil {
+		return server.Spec.AccessKey, server.Spec.SecretKey, nil
+	}
+
+	accessKeyKey := ref.AccessKeyKey
+	if accessKeyKey == "" {
+		accessKeyKey = defaultAccessKeyKey
+	}
+	secretKeyKey := ref.SecretKeyKey
+	if secretKeyKey == "" {
+		secretKeyKey = defaultSecretKeyKey
+	}
+
+	accessKey, err := getSecretKey(ctx, c, ref.Namespace, ref.Name, accessKeyKey)
+	if err != nil {
+		return "", "", fmt.Errorf("getSecretKey: %w", err)
+	}
+	secretKey, err := getSecretKey(ctx, c, ref.Namespace, ref.Name, secretKeyKey)
+	if err != nil {
+		return "", "", fmt.Errorf("getSecretKey: %w", err)
+	}
+
+	return string(accessKey), string(secretKey), nil
+}
+
+// getSecretKey return the value of a key of a Secret
+func getSecretKey(ctx context.Context, c client.Client, namespace, name, key string) ([]byte, error) {
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, secret); err != nil {
+		return nil, fmt.Errorf("c.Get: %w", err)
+	}
+
+	value, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("key %q not found in secret %s/%s", key, namespace, name)
+	}
+	return value, nil
+}
+
+// ServersUsingSecret return the name of all MinioServer reading their credentials or TLS options from a Secret
+func ServersUsingSecret(ctx context.Context, c client.Client, namespace, name string) ([]string, error) {
+	servers := &miniov1alpha1.MinioServerList{}
+	if err := c.List(ctx, servers); err != nil {
+		return nil, fmt.Errorf("c.List: %w", err)
+	}
+
+	names := []string{}
+	for _, server := range servers.Items {
+		if isSecretUsed(&server.Spec, namespace, name) {
+			names = append(names, server.Name)
+		}
+	}
+	return names, nil
+}
+
+// isSecretUsed return true if a MinioServer spec references a Secret
+func isSecretUsed(spec *miniov1alpha1.MinioServerSpec, namespace, name string) bool {
+	for _, ref := range secretsOf(spec) {
+		if ref.Namespace == namespace && ref.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// secretsOf return all Secrets referenced by a MinioServer spec
+func secretsOf(spec *miniov1alpha1.MinioServerSpec) []types.NamespacedName {
+	refs := []types.NamespacedName{}
+	if ref := spec.CredentialsSecretRef; ref != nil {
+		refs = append(refs, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name})
+	}
+	if spec.TLS == nil {
+		return refs
+	}
+	if ref := spec.TLS.CASecretRef; ref != nil {
+		refs = append(refs, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name})
+	}
+	if ref := spec.TLS.ClientCertSecretRef; ref != nil {
+		refs = append(refs, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name})
+	}
+	return refs
+}
@@ -0,0 +1,890 @@
+// Package fake provide an in-memory Minio server implementing the minioclient interfaces, for tests.
+// It records calls, and returns injected errors.
+package fake
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go"
+	"github.com/minio/minio/pkg/madmin"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/minioclient"
+	"github.com/robotinfra/minio-resources-operator/pkg/utils"
+)
+
+// noSuchGroupCode is the error code of Minio admin API when a group doesn't exist
+const noSuchGroupCode = "XMinioAdminNoSuchGroup"
+
+// Call is a call to the fake server, with the name of the bucket, user, policy or group it targets
+type Call struct {
+	Method string
+	Target string
+}
+
+// Bucket is a bucket of the fake server
+type Bucket struct {
+	CreationDate time.Time
+	Policy       string
+	Lifecycle    string
+	Notification minio.BucketNotification
+	Versioning   string
+	Tags         map[string]string
+	Objects      []string
+	Versions     []minioclient.ObjectVersion
+	Uploads      []string
+	// Size is the size of the bucket reported in the data usage, nil until computed by the server
+	Size *uint64
+}
+
+// User is a user of the fake server
+type User struct {
+	SecretKey  string
+	PolicyName string
+	Status     madmin.AccountStatus
+}
+
+// Server is an in-memory Minio server, serving all MinioServer
+type Server struct {
+	mu       sync.Mutex
+	buckets  map[string]*Bucket
+	users    map[string]*User
+	policies map[string]string
+	groups   map[string]*madmin.GroupDesc
+	calls    []Call
+	errors   map[string]error
+}
+
+// blank assignments to verify that Server implements the minioclient interfaces
+var (
+	_ minioclient.Clients     = &Server{}
+	_ minioclient.BucketAdmin = &Server{}
+	_ minioclient.IAMAdmin    = &Server{}
+	_ minioclient.ServerAdmin = &Server{}
+)
+
+// NewServer return an empty fake server
+func NewServer() *Server {
+	return &Server{
+		buckets:  map[string]*Bucket{},
+		users:    map[string]*User{},
+		policies: map[string]string{},
+		groups:   map[string]*madmin.GroupDesc{},
+		errors:   map[string]error{},
+	}
+}
+
+// SetError make all calls of a method return an error, or succeed again with a nil error.
+// BucketAdmin, IAMAdmin and ServerAdmin can also fail, as when a client can't be built.
+func (s *Server) SetError(method string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		delete(s.errors, method)
+		return
+	}
+	s.errors[method] = err
+}
+
+// Calls return the calls recorded since the server creation or the last ResetCalls
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call{}, s.calls...)
+}
+
+// CallCount return the number of recorded calls of a method
+func (s *Server) CallCount(method string) int {
+	count := 0
+	for _, c := range s.Calls() {
+		if c.Method == method {
+			count++
+		}
+	}
+	return count
+}
+
+// ResetCalls forget the recorded calls
+func (s *Server) ResetCalls() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = nil
+}
+
+// GetBucket return a copy of a bucket
+func (s *Server) GetBucket(name string) (Bucket, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[name]
+	if !ok {
+		return Bucket{}, false
+	}
+	bucket := *b
+	bucket.Tags = copyTags(b.Tags)
+	bucket.Objects = append([]string{}, b.Objects...)
+	bucket.Versions = append([]minioclient.ObjectVersion{}, b.Versions...)
+	bucket.Uploads = append([]string{}, b.Uploads...)
+	return bucket, true
+}
+
+// PutObjects add objects to a bucket
+func (s *Server) PutObjects(bucket string, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[bucket]
+	if !ok {
+		return noSuchBucket(bucket)
+	}
+	b.Objects = append(b.Objects, keys...)
+	return nil
+}
+
+// SetBucketSize set the size of a bucket reported in the data usage
+func (s *Server) SetBucketSize(bucket string, size uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[bucket]
+	if !ok {
+		return noSuchBucket(bucket)
+	}
+	b.Size = &size
+	return nil
+}
+
+// GetUser return a copy of a user
+func (s *Server) GetUser(accessKey string) (User, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[accessKey]
+	if !ok {
+		return User{}, false
+	}
+	return *u, true
+}
+
+// GetPolicy return a canned policy
+func (s *Server) GetPolicy(name string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.policies[name]
+	return p, ok
+}
+
+// GetGroup return a copy of a group
+func (s *Server) GetGroup(name string) (madmin.GroupDesc, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.groups[name]
+	if !ok {
+		return madmin.GroupDesc{}, false
+	}
+	group := *g
+	group.Members = append([]string{}, g.Members...)
+	return group, true
+}
+
+// call record a call and return its injected error, s.mu must be locked
+func (s *Server) call(method, target string) error {
+	s.calls = append(s.calls, Call{Method: method, Target: target})
+	return s.errors[method]
+}
+
+// BucketAdmin implements minioclient.Clients
+func (s *Server) BucketAdmin(ctx context.Context, server *miniov1alpha1.MinioServer) (minioclient.BucketAdmin, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("BucketAdmin", server.Name); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// IAMAdmin implements minioclient.Clients
+func (s *Server) IAMAdmin(ctx context.Context, server *miniov1alpha1.MinioServer) (minioclient.IAMAdmin, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("IAMAdmin", server.Name); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ServerAdmin implements minioclient.Clients
+func (s *Server) ServerAdmin(ctx context.Context, server *miniov1alpha1.MinioServer) (minioclient.ServerAdmin, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("ServerAdmin", server.Name); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// CheckLiveness implements minioclient.Clients
+func (s *Server) CheckLiveness(ctx context.Context, server *miniov1alpha1.MinioServer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.call("CheckLiveness", server.Name)
+}
+
+// Forget implements minioclient.Clients, the fake server having no clients to drop
+func (s *Server) Forget(server string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.call("Forget", server)
+}
+
+// ServerInfo implements minioclient.ServerAdmin, the fake server being a single node with a single drive
+func (s *Server) ServerInfo() (madmin.InfoMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("ServerInfo", ""); err != nil {
+		return madmin.InfoMessage{}, err
+	}
+	return madmin.InfoMessage{
+		Mode:   "online",
+		Region: "us-east-1",
+		Servers: []madmin.ServerProperties{{
+			State:   "ok",
+			Version: "fake",
+			Disks:   []madmin.Disk{{DrivePath: "/data", State: "ok"}},
+		}},
+	}, nil
+}
+
+// DataUsageInfo implements minioclient.ServerAdmin, with the sizes of buckets and their number of objects
+func (s *Server) DataUsageInfo() (madmin.DataUsageInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("DataUsageInfo", ""); err != nil {
+		return madmin.DataUsageInfo{}, err
+	}
+	usage := madmin.DataUsageInfo{
+		LastUpdate:   time.Now(),
+		BucketsCount: uint64(len(s.buckets)),
+		BucketsSizes: map[string]uint64{},
+	}
+	for name, b := range s.buckets {
+		usage.ObjectsCount += uint64(len(b.Objects))
+		if b.Size != nil {
+			usage.BucketsSizes[name] = *b.Size
+			usage.ObjectsTotalSize += *b.Size
+		}
+	}
+	return usage, nil
+}
+
+// bucket return an existing bucket, s.mu must be locked
+func (s *Server) bucket(name string) (*Bucket, error) {
+	b, ok := s.buckets[name]
+	if !ok {
+		return nil, noSuchBucket(name)
+	}
+	return b, nil
+}
+
+// BucketExists implements minioclient.BucketAdmin
+func (s *Server) BucketExists(bucket string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("BucketExists", bucket); err != nil {
+		return false, err
+	}
+	_, ok := s.buckets[bucket]
+	return ok, nil
+}
+
+// MakeBucket implements minioclient.BucketAdmin
+func (s *Server) MakeBucket(bucket, location string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("MakeBucket", bucket); err != nil {
+		return err
+	}
+	if _, ok := s.buckets[bucket]; ok {
+		return minio.ErrorResponse{Code: "BucketAlreadyOwnedByYou", StatusCode: http.StatusConflict, BucketName: bucket}
+	}
+	s.buckets[bucket] = &Bucket{CreationDate: time.Now().UTC().Truncate(time.Second), Tags: map[string]string{}}
+	return nil
+}
+
+// RemoveBucket implements minioclient.BucketAdmin
+func (s *Server) RemoveBucket(bucket string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("RemoveBucket", bucket); err != nil {
+		return err
+	}
+	b, err := s.bucket(bucket)
+	if err != nil {
+		return err
+	}
+	if len(b.Objects) > 0 || len(b.Versions) > 0 {
+		return minio.ErrorResponse{Code: "BucketNotEmpty", StatusCode: http.StatusConflict, BucketName: bucket}
+	}
+	delete(s.buckets, bucket)
+	return nil
+}
+
+// ListBuckets implements minioclient.BucketAdmin
+func (s *Server) ListBuckets() ([]minio.BucketInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("ListBuckets", ""); err != nil {
+		return nil, err
+	}
+	buckets := []minio.BucketInfo{}
+	for name, b := range s.buckets {
+		buckets = append(buckets, minio.BucketInfo{Name: name, CreationDate: b.CreationDate})
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Name < buckets[j].Name })
+	return buckets, nil
+}
+
+// GetBucketPolicy implements minioclient.BucketAdmin
+func (s *Server) GetBucketPolicy(bucket string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("GetBucketPolicy", bucket); err != nil {
+		return "", err
+	}
+	b, err := s.bucket(bucket)
+	if err != nil {
+		return "", err
+	}
+	return b.Policy, nil
+}
+
+// SetBucketPolicy implements minioclient.BucketAdmin
+func (s *Server) SetBucketPolicy(bucket, policy string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("SetBucketPolicy", bucket); err != nil {
+		return err
+	}
+	b, err := s.bucket(bucket)
+	if err != nil {
+		return err
+	}
+	b.Policy = policy
+	return nil
+}
+
+// GetBucketLifecycle implements minioclient.BucketAdmin
+func (s *Server) GetBucketLifecycle(bucket string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("GetBucketLifecycle", bucket); err != nil {
+		return "", err
+	}
+	b, err := s.bucket(bucket)
+	if err != nil {
+		return "", err
+	}
+	return b.Lifecycle, nil
+}
+
+// SetBucketLifecycle implements minioclient.BucketAdmin
+func (s *Server) SetBucketLifecycle(bucket, lifecycle string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("SetBucketLifecycle", bucket); err != nil {
+		return err
+	}
+	b, err := s.bucket(bucket)
+	if err != nil {
+		return err
+	}
+	b.Lifecycle = lifecycle
+	return nil
+}
+
+// GetBucketNotification implements minioclient.BucketAdmin
+func (s *Server) GetBucketNotification(bucket string) (minio.BucketNotification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("GetBucketNotification", bucket); err != nil {
+		return minio.BucketNotification{}, err
+	}
+	b, err := s.bucket(bucket)
+	if err != nil {
+		return minio.BucketNotification{}, err
+	}
+	return b.Notification, nil
+}
+
+// SetBucketNotification implements minioclient.BucketAdmin
+func (s *Server) SetBucketNotification(bucket string, notification minio.BucketNotification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("SetBucketNotification", bucket); err != nil {
+		return err
+	}
+	b, err := s.bucket(bucket)
+	if err != nil {
+		return err
+	}
+	b.Notification = notification
+	return nil
+}
+
+// RemoveAllBucketNotification implements minioclient.BucketAdmin
+func (s *Server) RemoveAllBucketNotification(bucket string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("RemoveAllBucketNotification", bucket); err != nil {
+		return err
+	}
+	b, err := s.bucket(bucket)
+	if err != nil {
+		return err
+	}
+	b.Notification = minio.BucketNotification{}
+	return nil
+}
+
+// ListObjectsV2 implements minioclient.BucketAdmin
+func (s *Server) ListObjectsV2(bucket, prefix string, recursive bool, doneCh <-chan struct{}) <-chan minio.ObjectInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	objects := []minio.ObjectInfo{}
+	if err := s.call("ListObjectsV2", bucket); err != nil {
+		objects = append(objects, minio.ObjectInfo{Err: err})
+	} else if b, err := s.bucket(bucket); err != nil {
+		objects = append(objects, minio.ObjectInfo{Err: err})
+	} else {
+		for _, key := range b.Objects {
+			objects = append(objects, minio.ObjectInfo{Key: key})
+		}
+	}
+
+	objectsCh := make(chan minio.ObjectInfo, len(objects))
+	for _, object := range objects {
+		objectsCh <- object
+	}
+	close(objectsCh)
+	return objectsCh
+}
+
+// RemoveObjects implements minioclient.BucketAdmin
+func (s *Server) RemoveObjects(bucket string, objectsCh <-chan string) <-chan minio.RemoveObjectError {
+	keys := []string{}
+	for key := range objectsCh {
+		keys = append(keys, key)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	errs := []minio.RemoveObjectError{}
+	if err := s.call("RemoveObjects", bucket); err != nil {
+		for _, key := range keys {
+			errs = append(errs, minio.RemoveObjectError{ObjectName: key, Err: err})
+		}
+	} else if b, err := s.bucket(bucket); err != nil {
+		for _, key := range keys {
+			errs = append(errs, minio.RemoveObjectError{ObjectName: key, Err: err})
+		}
+	} else {
+		for _, key := range keys {
+			b.Objects = utils.Remove(b.Objects, key)
+		}
+	}
+
+	errCh := make(chan minio.RemoveObjectError, len(errs))
+	for _, err := range errs {
+		errCh <- err
+	}
+	close(errCh)
+	return errCh
+}
+
+// ListIncompleteUploads implements minioclient.BucketAdmin
+func (s *Server) ListIncompleteUploads(bucket, prefix string, recursive bool, doneCh <-chan struct{}) <-chan minio.ObjectMultipartInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	uploads := []minio.ObjectMultipartInfo{}
+	if err := s.call("ListIncompleteUploads", bucket); err != nil {
+		uploads = append(uploads, minio.ObjectMultipartInfo{Err: err})
+	} else if b, err := s.bucket(bucket); err != nil {
+		uploads = append(uploads, minio.ObjectMultipartInfo{Err: err})
+	} else {
+		for _, key := range b.Uploads {
+			uploads = append(uploads, minio.ObjectMultipartInfo{Key: key})
+		}
+	}
+
+	uploadsCh := make(chan minio.ObjectMultipartInfo, len(uploads))
+	for _, upload := range uploads {
+		uploadsCh <- upload
+	}
+	close(uploadsCh)
+	return uploadsCh
+}
+
+// RemoveIncompleteUpload implements minioclient.BucketAdmin
+func (s *Server) RemoveIncompleteUpload(bucket, object string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("RemoveIncompleteUpload", bucket); err != nil {
+		return err
+	}
+	b, err := s.bucket(bucket)
+	if err != nil {
+		return err
+	}
+	b.Uploads = utils.Remove(b.Uploads, object)
+	return nil
+}
+
+// GetBucketVersioning implements minioclient.BucketAdmin
+func (s *Server) GetBucketVersioning(ctx context.Context, bucket string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("GetBucketVersioning", bucket); err != nil {
+		return "", err
+	}
+	b, err := s.bucket(bucket)
+	if err != nil {
+		return "", err
+	}
+	return b.Versioning, nil
+}
+
+// SetBucketVersioning implements minioclient.BucketAdmin
+func (s *Server) SetBucketVersioning(ctx context.Context, bucket, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("SetBucketVersioning", bucket); err != nil {
+		return err
+	}
+	b, err := s.bucket(bucket)
+	if err != nil {
+		return err
+	}
+	b.Versioning = status
+	return nil
+}
+
+// ListObjectVersions implements minioclient.BucketAdmin
+func (s *Server) ListObjectVersions(ctx context.Context, bucket string, maxKeys int) ([]minioclient.ObjectVersion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("ListObjectVersions", bucket); err != nil {
+		return nil, err
+	}
+	b, err := s.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+	versions := b.Versions
+	if len(versions) > maxKeys {
+		versions = versions[:maxKeys]
+	}
+	return append([]minioclient.ObjectVersion{}, versions...), nil
+}
+
+// RemoveObjectVersion implements minioclient.BucketAdmin
+func (s *Server) RemoveObjectVersion(ctx context.Context, bucket string, version minioclient.ObjectVersion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("RemoveObjectVersion", bucket); err != nil {
+		return err
+	}
+	b, err := s.bucket(bucket)
+	if err != nil {
+		return err
+	}
+	for i, v := range b.Versions {
+		if v == version {
+			b.Versions = append(b.Versions[:i], b.Versions[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// GetBucketTags implements minioclient.BucketAdmin
+func (s *Server) GetBucketTags(ctx context.Context, bucket string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("GetBucketTags", bucket); err != nil {
+		return nil, err
+	}
+	b, err := s.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+	return copyTags(b.Tags), nil
+}
+
+// SetBucketTags implements minioclient.BucketAdmin
+func (s *Server) SetBucketTags(ctx context.Context, bucket string, tags map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("SetBucketTags", bucket); err != nil {
+		return err
+	}
+	b, err := s.bucket(bucket)
+	if err != nil {
+		return err
+	}
+	b.Tags = copyTags(tags)
+	return nil
+}
+
+// ListUsers implements minioclient.IAMAdmin
+func (s *Server) ListUsers() (map[string]madmin.UserInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("ListUsers", ""); err != nil {
+		return nil, err
+	}
+	users := map[string]madmin.UserInfo{}
+	for accessKey, u := range s.users {
+		info := madmin.UserInfo{PolicyName: u.PolicyName, Status: u.Status}
+		for name, g := range s.groups {
+			if utils.Contains(g.Members, accessKey) {
+				info.MemberOf = append(info.MemberOf, name)
+			}
+		}
+		sort.Strings(info.MemberOf)
+		users[accessKey] = info
+	}
+	return users, nil
+}
+
+// AddUser implements minioclient.IAMAdmin
+func (s *Server) AddUser(accessKey, secretKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("AddUser", accessKey); err != nil {
+		return err
+	}
+	return s.setUser(accessKey, secretKey, madmin.AccountEnabled)
+}
+
+// SetUser implements minioclient.IAMAdmin
+func (s *Server) SetUser(accessKey, secretKey string, status madmin.AccountStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("SetUser", accessKey); err != nil {
+		return err
+	}
+	return s.setUser(accessKey, secretKey, status)
+}
+
+// setUser create or update a user, s.mu must be locked
+func (s *Server) setUser(accessKey, secretKey string, status madmin.AccountStatus) error {
+	u, ok := s.users[accessKey]
+	if !ok {
+		u = &User{}
+		s.users[accessKey] = u
+	}
+	u.SecretKey = secretKey
+	u.Status = status
+	return nil
+}
+
+// SetUserStatus implements minioclient.IAMAdmin
+func (s *Server) SetUserStatus(accessKey string, status madmin.AccountStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("SetUserStatus", accessKey); err != nil {
+		return err
+	}
+	u, ok := s.users[accessKey]
+	if !ok {
+		return adminError("XMinioAdminNoSuchUser", fmt.Sprintf("user %s does not exist", accessKey))
+	}
+	u.Status = status
+	return nil
+}
+
+// RemoveUser implements minioclient.IAMAdmin
+func (s *Server) RemoveUser(accessKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("RemoveUser", accessKey); err != nil {
+		return err
+	}
+	if _, ok := s.users[accessKey]; !ok {
+		return adminError("XMinioAdminNoSuchUser", fmt.Sprintf("user %s does not exist", accessKey))
+	}
+	delete(s.users, accessKey)
+	for _, g := range s.groups {
+		g.Members = utils.Remove(g.Members, accessKey)
+	}
+	return nil
+}
+
+// ListCannedPolicies implements minioclient.IAMAdmin
+func (s *Server) ListCannedPolicies() (map[string][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("ListCannedPolicies", ""); err != nil {
+		return nil, err
+	}
+	policies := map[string][]byte{}
+	for name, p := range s.policies {
+		policies[name] = []byte(p)
+	}
+	return policies, nil
+}
+
+// AddCannedPolicy implements minioclient.IAMAdmin
+func (s *Server) AddCannedPolicy(name, policy string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("AddCannedPolicy", name); err != nil {
+		return err
+	}
+	s.policies[name] = policy
+	return nil
+}
+
+// RemoveCannedPolicy implements minioclient.IAMAdmin
+func (s *Server) RemoveCannedPolicy(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("RemoveCannedPolicy", name); err != nil {
+		return err
+	}
+	delete(s.policies, name)
+	return nil
+}
+
+// SetPolicy implements minioclient.IAMAdmin
+func (s *Server) SetPolicy(policyName, entityName string, isGroup bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("SetPolicy", entityName); err != nil {
+		return err
+	}
+	// Several policies can be attached, comma separated
+	if policyName != "" {
+		for _, name := range strings.Split(policyName, ",") {
+			if _, ok := s.policies[name]; !ok {
+				return adminError("XMinioAdminNoSuchPolicy", fmt.Sprintf("policy %s does not exist", name))
+			}
+		}
+	}
+	if isGroup {
+		g, ok := s.groups[entityName]
+		if !ok {
+			return adminError(noSuchGroupCode, fmt.Sprintf("group %s does not exist", entityName))
+		}
+		g.Policy = policyName
+		return nil
+	}
+	u, ok := s.users[entityName]
+	if !ok {
+		return adminError("XMinioAdminNoSuchUser", fmt.Sprintf("user %s does not exist", entityName))
+	}
+	u.PolicyName = policyName
+	return nil
+}
+
+// GetGroupDescription implements minioclient.IAMAdmin
+func (s *Server) GetGroupDescription(group string) (*madmin.GroupDesc, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("GetGroupDescription", group); err != nil {
+		return nil, err
+	}
+	g, ok := s.groups[group]
+	if !ok {
+		return nil, adminError(noSuchGroupCode, fmt.Sprintf("group %s does not exist", group))
+	}
+	desc := *g
+	desc.Members = append([]string{}, g.Members...)
+	return &desc, nil
+}
+
+// UpdateGroupMembers implements minioclient.IAMAdmin.
+// As Minio, removing no members from a group without members removes the group.
+func (s *Server) UpdateGroupMembers(members madmin.GroupAddRemove) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("UpdateGroupMembers", members.Group); err != nil {
+		return err
+	}
+
+	g, ok := s.groups[members.Group]
+	if members.IsRemove {
+		if !ok {
+			return adminError(noSuchGroupCode, fmt.Sprintf("group %s does not exist", members.Group))
+		}
+		if len(members.Members) == 0 {
+			if len(g.Members) > 0 {
+				return adminError("XMinioAdminGroupNotEmpty", fmt.Sprintf("group %s is not empty", members.Group))
+			}
+			delete(s.groups, members.Group)
+			return nil
+		}
+		for _, member := range members.Members {
+			g.Members = utils.Remove(g.Members, member)
+		}
+		return nil
+	}
+
+	for _, member := range members.Members {
+		if _, ok := s.users[member]; !ok {
+			return adminError("XMinioAdminNoSuchUser", fmt.Sprintf("user %s does not exist", member))
+		}
+	}
+	if !ok {
+		g = &madmin.GroupDesc{Name: members.Group, Status: string(madmin.GroupEnabled), Members: []string{}}
+		s.groups[members.Group] = g
+	}
+	for _, member := range members.Members {
+		if !utils.Contains(g.Members, member) {
+			g.Members = append(g.Members, member)
+		}
+	}
+	sort.Strings(g.Members)
+	return nil
+}
+
+// SetGroupStatus implements minioclient.IAMAdmin
+func (s *Server) SetGroupStatus(group string, status madmin.GroupStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("SetGroupStatus", group); err != nil {
+		return err
+	}
+	g, ok := s.groups[group]
+	if !ok {
+		return adminError(noSuchGroupCode, fmt.Sprintf("group %s does not exist", group))
+	}
+	g.Status = string(status)
+	return nil
+}
+
+// noSuchBucket return the S3 error of a missing bucket
+func noSuchBucket(bucket string) error {
+	return minio.ErrorResponse{
+		Code:       "NoSuchBucket",
+		Message:    "The specified bucket does not exist",
+		BucketName: bucket,
+		StatusCode: http.StatusNotFound,
+	}
+}
+
+// adminError return an error of the Minio admin API
+func adminError(code, message string) error {
+	return madmin.ErrorResponse{Code: code, Message: message}
+}
+
+// copyTags return a copy of bucket tags
+func copyTags(tags map[string]string) map[string]string {
+	result := map[string]string{}
+	for key, value := range tags {
+		result[key] = value
+	}
+	return result
+}
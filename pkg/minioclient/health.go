@@ -0,0 +1,34 @@
+package minioclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+// CheckLiveness call the unauthenticated liveness endpoint of a MinioServer
+func (p *Pool) CheckLiveness(ctx context.Context, server *miniov1alpha1.MinioServer) error {
+	transport, err := p.Transport(ctx, server)
+	if err != nil {
+		return fmt.Errorf("p.Transport: %w", err)
+	}
+	httpClient := &http.Client{Transport: transport}
+
+	req, err := http.NewRequest(http.MethodGet, server.Spec.GetEndpointURL()+"/minio/health/live", nil)
+	if err != nil {
+		return fmt.Errorf("http.NewRequest: %w", err)
+	}
+
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("httpClient.Do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("liveness check returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,354 @@
+package minioclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/minio/minio-go"
+	"github.com/minio/minio/pkg/madmin"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/metrics"
+)
+
+// Instrument return clients recording the count, latency and errors of Minio API requests in metrics.
+// Requests returning a channel are recorded when the channel is closed, failed if it received an error.
+func Instrument(clients Clients) Clients {
+	return &instrumentedClients{clients: clients}
+}
+
+// blank assignments to verify that instrumented clients implement the interfaces
+var (
+	_ Clients     = &instrumentedClients{}
+	_ BucketAdmin = &instrumentedBucketAdmin{}
+	_ IAMAdmin    = &instrumentedIAMAdmin{}
+	_ ServerAdmin = &instrumentedServerAdmin{}
+)
+
+// instrumentedClients record metrics of the clients it returns
+type instrumentedClients struct {
+	clients Clients
+}
+
+// BucketAdmin implements Clients
+func (c *instrumentedClients) BucketAdmin(ctx context.Context, server *miniov1alpha1.MinioServer) (BucketAdmin, error) {
+	client, err := c.clients.BucketAdmin(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedBucketAdmin{client: client, server: server.Name}, nil
+}
+
+// IAMAdmin implements Clients
+func (c *instrumentedClients) IAMAdmin(ctx context.Context, server *miniov1alpha1.MinioServer) (IAMAdmin, error) {
+	client, err := c.clients.IAMAdmin(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedIAMAdmin{client: client, server: server.Name}, nil
+}
+
+// ServerAdmin implements Clients
+func (c *instrumentedClients) ServerAdmin(ctx context.Context, server *miniov1alpha1.MinioServer) (ServerAdmin, error) {
+	client, err := c.clients.ServerAdmin(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedServerAdmin{client: client, server: server.Name}, nil
+}
+
+// CheckLiveness implements Clients
+func (c *instrumentedClients) CheckLiveness(ctx context.Context, server *miniov1alpha1.MinioServer) (err error) {
+	defer func(start time.Time) { metrics.ObserveRequest(server.Name, "CheckLiveness", start, err) }(time.Now())
+	return c.clients.CheckLiveness(ctx, server)
+}
+
+// Forget implements Clients
+func (c *instrumentedClients) Forget(server string) {
+	c.clients.Forget(server)
+}
+
+// instrumentedBucketAdmin record metrics of the requests of a BucketAdmin
+type instrumentedBucketAdmin struct {
+	client BucketAdmin
+	server string
+}
+
+// observe record a request started at start, to be deferred
+func (b *instrumentedBucketAdmin) observe(operation string, start time.Time, err *error) {
+	metrics.ObserveRequest(b.server, operation, start, *err)
+}
+
+// BucketExists implements BucketAdmin
+func (b *instrumentedBucketAdmin) BucketExists(bucket string) (_ bool, err error) {
+	defer b.observe("BucketExists", time.Now(), &err)
+	return b.client.BucketExists(bucket)
+}
+
+// MakeBucket implements BucketAdmin
+func (b *instrumentedBucketAdmin) MakeBucket(bucket, location string) (err error) {
+	defer b.observe("MakeBucket", time.Now(), &err)
+	return b.client.MakeBucket(bucket, location)
+}
+
+// RemoveBucket implements BucketAdmin
+func (b *instrumentedBucketAdmin) RemoveBucket(bucket string) (err error) {
+	defer b.observe("RemoveBucket", time.Now(), &err)
+	return b.client.RemoveBucket(bucket)
+}
+
+// ListBuckets implements BucketAdmin
+func (b *instrumentedBucketAdmin) ListBuckets() (_ []minio.BucketInfo, err error) {
+	defer b.observe("ListBuckets", time.Now(), &err)
+	return b.client.ListBuckets()
+}
+
+// GetBucketPolicy implements BucketAdmin
+func (b *instrumentedBucketAdmin) GetBucketPolicy(bucket string) (_ string, err error) {
+	defer b.observe("GetBucketPolicy", time.Now(), &err)
+	return b.client.GetBucketPolicy(bucket)
+}
+
+// SetBucketPolicy implements BucketAdmin
+func (b *instrumentedBucketAdmin) SetBucketPolicy(bucket, policy string) (err error) {
+	defer b.observe("SetBucketPolicy", time.Now(), &err)
+	return b.client.SetBucketPolicy(bucket, policy)
+}
+
+// GetBucketLifecycle implements BucketAdmin
+func (b *instrumentedBucketAdmin) GetBucketLifecycle(bucket string) (_ string, err error) {
+	defer b.observe("GetBucketLifecycle", time.Now(), &err)
+	return b.client.GetBucketLifecycle(bucket)
+}
+
+// SetBucketLifecycle implements BucketAdmin
+func (b *instrumentedBucketAdmin) SetBucketLifecycle(bucket, lifecycle string) (err error) {
+	defer b.observe("SetBucketLifecycle", time.Now(), &err)
+	return b.client.SetBucketLifecycle(bucket, lifecycle)
+}
+
+// GetBucketNotification implements BucketAdmin
+func (b *instrumentedBucketAdmin) GetBucketNotification(bucket string) (_ minio.BucketNotification, err error) {
+	defer b.observe("GetBucketNotification", time.Now(), &err)
+	return b.client.GetBucketNotification(bucket)
+}
+
+// SetBucketNotification implements BucketAdmin
+func (b *instrumentedBucketAdmin) SetBucketNotification(bucket string, notification minio.BucketNotification) (err error) {
+	defer b.observe("SetBucketNotification", time.Now(), &err)
+	return b.client.SetBucketNotification(bucket, notification)
+}
+
+// RemoveAllBucketNotification implements BucketAdmin
+func (b *instrumentedBucketAdmin) RemoveAllBucketNotification(bucket string) (err error) {
+	defer b.observe("RemoveAllBucketNotification", time.Now(), &err)
+	return b.client.RemoveAllBucketNotification(bucket)
+}
+
+// ListObjectsV2 implements BucketAdmin
+func (b *instrumentedBucketAdmin) ListObjectsV2(bucket, prefix string, recursive bool, doneCh <-chan struct{}) <-chan minio.ObjectInfo {
+	start := time.Now()
+	objectsCh := b.client.ListObjectsV2(bucket, prefix, recursive, doneCh)
+	observedCh := make(chan minio.ObjectInfo)
+	go func() {
+		defer close(observedCh)
+		var err error
+		for object := range objectsCh {
+			if err == nil {
+				err = object.Err
+			}
+			// Objects listed after doneCh is closed are dropped, until the listing stops
+			select {
+			case observedCh <- object:
+			case <-doneCh:
+			}
+		}
+		metrics.ObserveRequest(b.server, "ListObjectsV2", start, err)
+	}()
+	return observedCh
+}
+
+// RemoveObjects implements BucketAdmin
+func (b *instrumentedBucketAdmin) RemoveObjects(bucket string, objectsCh <-chan string) <-chan minio.RemoveObjectError {
+	start := time.Now()
+	errorsCh := b.client.RemoveObjects(bucket, objectsCh)
+	observedCh := make(chan minio.RemoveObjectError)
+	go func() {
+		defer close(observedCh)
+		var err error
+		for removeErr := range errorsCh {
+			if err == nil {
+				err = removeErr.Err
+			}
+			observedCh <- removeErr
+		}
+		metrics.ObserveRequest(b.server, "RemoveObjects", start, err)
+	}()
+	return observedCh
+}
+
+// ListIncompleteUploads implements BucketAdmin
+func (b *instrumentedBucketAdmin) ListIncompleteUploads(bucket, prefix string, recursive bool, doneCh <-chan struct{}) <-chan minio.ObjectMultipartInfo {
+	start := time.Now()
+	uploadsCh := b.client.ListIncompleteUploads(bucket, prefix, recursive, doneCh)
+	observedCh := make(chan minio.ObjectMultipartInfo)
+	go func() {
+		defer close(observedCh)
+		var err error
+		for upload := range uploadsCh {
+			if err == nil {
+				err = upload.Err
+			}
+			// Uploads listed after doneCh is closed are dropped, until the listing stops
+			select {
+			case observedCh <- upload:
+			case <-doneCh:
+			}
+		}
+		metrics.ObserveRequest(b.server, "ListIncompleteUploads", start, err)
+	}()
+	return observedCh
+}
+
+// RemoveIncompleteUpload implements BucketAdmin
+func (b *instrumentedBucketAdmin) RemoveIncompleteUpload(bucket, object string) (err error) {
+	defer b.observe("RemoveIncompleteUpload", time.Now(), &err)
+	return b.client.RemoveIncompleteUpload(bucket, object)
+}
+
+// GetBucketVersioning implements BucketAdmin
+func (b *instrumentedBucketAdmin) GetBucketVersioning(ctx context.Context, bucket string) (_ string, err error) {
+	defer b.observe("GetBucketVersioning", time.Now(), &err)
+	return b.client.GetBucketVersioning(ctx, bucket)
+}
+
+// SetBucketVersioning implements BucketAdmin
+func (b *instrumentedBucketAdmin) SetBucketVersioning(ctx context.Context, bucket, status string) (err error) {
+	defer b.observe("SetBucketVersioning", time.Now(), &err)
+	return b.client.SetBucketVersioning(ctx, bucket, status)
+}
+
+// ListObjectVersions implements BucketAdmin
+func (b *instrumentedBucketAdmin) ListObjectVersions(ctx context.Context, bucket string, maxKeys int) (_ []ObjectVersion, err error) {
+	defer b.observe("ListObjectVersions", time.Now(), &err)
+	return b.client.ListObjectVersions(ctx, bucket, maxKeys)
+}
+
+// RemoveObjectVersion implements BucketAdmin
+func (b *instrumentedBucketAdmin) RemoveObjectVersion(ctx context.Context, bucket string, version ObjectVersion) (err error) {
+	defer b.observe("RemoveObjectVersion", time.Now(), &err)
+	return b.client.RemoveObjectVersion(ctx, bucket, version)
+}
+
+// GetBucketTags implements BucketAdmin
+func (b *instrumentedBucketAdmin) GetBucketTags(ctx context.Context, bucket string) (_ map[string]string, err error) {
+	defer b.observe("GetBucketTags", time.Now(), &err)
+	return b.client.GetBucketTags(ctx, bucket)
+}
+
+// SetBucketTags implements BucketAdmin
+func (b *instrumentedBucketAdmin) SetBucketTags(ctx context.Context, bucket string, tags map[string]string) (err error) {
+	defer b.observe("SetBucketTags", time.Now(), &err)
+	return b.client.SetBucketTags(ctx, bucket, tags)
+}
+
+// instrumentedIAMAdmin record metrics of the requests of an IAMAdmin
+type instrumentedIAMAdmin struct {
+	client IAMAdmin
+	server string
+}
+
+// observe record a request started at start, to be deferred
+func (a *instrumentedIAMAdmin) observe(operation string, start time.Time, err *error) {
+	metrics.ObserveRequest(a.server, operation, start, *err)
+}
+
+// ListUsers implements IAMAdmin
+func (a *instrumentedIAMAdmin) ListUsers() (_ map[string]madmin.UserInfo, err error) {
+	defer a.observe("ListUsers", time.Now(), &err)
+	return a.client.ListUsers()
+}
+
+// AddUser implements IAMAdmin
+func (a *instrumentedIAMAdmin) AddUser(accessKey, secretKey string) (err error) {
+	defer a.observe("AddUser", time.Now(), &err)
+	return a.client.AddUser(accessKey, secretKey)
+}
+
+// SetUser implements IAMAdmin
+func (a *instrumentedIAMAdmin) SetUser(accessKey, secretKey string, status madmin.AccountStatus) (err error) {
+	defer a.observe("SetUser", time.Now(), &err)
+	return a.client.SetUser(accessKey, secretKey, status)
+}
+
+// SetUserStatus implements IAMAdmin
+func (a *instrumentedIAMAdmin) SetUserStatus(accessKey string, status madmin.AccountStatus) (err error) {
+	defer a.observe("SetUserStatus", time.Now(), &err)
+	return a.client.SetUserStatus(accessKey, status)
+}
+
+// RemoveUser implements IAMAdmin
+func (a *instrumentedIAMAdmin) RemoveUser(accessKey string) (err error) {
+	defer a.observe("RemoveUser", time.Now(), &err)
+	return a.client.RemoveUser(accessKey)
+}
+
+// ListCannedPolicies implements IAMAdmin
+func (a *instrumentedIAMAdmin) ListCannedPolicies() (_ map[string][]byte, err error) {
+	defer a.observe("ListCannedPolicies", time.Now(), &err)
+	return a.client.ListCannedPolicies()
+}
+
+// AddCannedPolicy implements IAMAdmin
+func (a *instrumentedIAMAdmin) AddCannedPolicy(name, policy string) (err error) {
+	defer a.observe("AddCannedPolicy", time.Now(), &err)
+	return a.client.AddCannedPolicy(name, policy)
+}
+
+// RemoveCannedPolicy implements IAMAdmin
+func (a *instrumentedIAMAdmin) RemoveCannedPolicy(name string) (err error) {
+	defer a.observe("RemoveCannedPolicy", time.Now(), &err)
+	return a.client.RemoveCannedPolicy(name)
+}
+
+// SetPolicy implements IAMAdmin
+func (a *instrumentedIAMAdmin) SetPolicy(policyName, entityName string, isGroup bool) (err error) {
+	defer a.observe("SetPolicy", time.Now(), &err)
+	return a.client.SetPolicy(policyName, entityName, isGroup)
+}
+
+// GetGroupDescription implements IAMAdmin
+func (a *instrumentedIAMAdmin) GetGroupDescription(group string) (_ *madmin.GroupDesc, err error) {
+	defer a.observe("GetGroupDescription", time.Now(), &err)
+	return a.client.GetGroupDescription(group)
+}
+
+// UpdateGroupMembers implements IAMAdmin
+func (a *instrumentedIAMAdmin) UpdateGroupMembers(members madmin.GroupAddRemove) (err error) {
+	defer a.observe("UpdateGroupMembers", time.Now(), &err)
+	return a.client.UpdateGroupMembers(members)
+}
+
+// SetGroupStatus implements IAMAdmin
+func (a *instrumentedIAMAdmin) SetGroupStatus(group string, status madmin.GroupStatus) (err error) {
+	defer a.observe("SetGroupStatus", time.Now(), &err)
+	return a.client.SetGroupStatus(group, status)
+}
+
+// instrumentedServerAdmin record metrics of the requests of a ServerAdmin
+type instrumentedServerAdmin struct {
+	client ServerAdmin
+	server string
+}
+
+// ServerInfo implements ServerAdmin
+func (s *instrumentedServerAdmin) ServerInfo() (_ madmin.InfoMessage, err error) {
+	defer func(start time.Time) { metrics.ObserveRequest(s.server, "ServerInfo", start, err) }(time.Now())
+	return s.client.ServerInfo()
+}
+
+// DataUsageInfo implements ServerAdmin
+func (s *instrumentedServerAdmin) DataUsageInfo() (_ madmin.DataUsageInfo, err error) {
+	defer func(start time.Time) { metrics.ObserveRequest(s.server, "DataUsageInfo", start, err) }(time.Now())
+	return s.client.DataUsageInfo()
+}
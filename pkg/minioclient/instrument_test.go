@@ -0,0 +1,81 @@
+package minioclient_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/minioclient"
+	miniofake "github.com/robotinfra/minio-resources-operator/pkg/minioclient/fake"
+)
+
+// requestMetrics return the number of requests, failed requests and observed durations of an operation
+func requestMetrics(t *testing.T, server, operation string) (requests, failures float64, durations uint64) {
+	t.Helper()
+	families, err := ctrlmetrics.Registry.Gather()
+	if err != nil {
+		t.Fatalf("ctrlmetrics.Registry.Gather: %v", err)
+	}
+	for _, family := range families {
+	metrics:
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if (label.GetName() == "server" && label.GetValue() != server) || (label.GetName() == "operation" && label.GetValue() != operation) {
+					continue metrics
+				}
+			}
+			switch family.GetName() {
+			case "minio_operator_api_requests_total":
+				requests = m.GetCounter().GetValue()
+			case "minio_operator_api_request_errors_total":
+				failures = m.GetCounter().GetValue()
+			case "minio_operator_api_request_duration_seconds":
+				durations = m.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return requests, failures, durations
+}
+
+func TestInstrumentChannels(t *testing.T) {
+	fake := miniofake.NewServer()
+	if err := fake.MakeBucket("mybucket", ""); err != nil {
+		t.Fatalf("fake.MakeBucket: %v", err)
+	}
+	if err := fake.PutObjects("mybucket", "a", "b"); err != nil {
+		t.Fatalf("fake.PutObjects: %v", err)
+	}
+	server := &miniov1alpha1.MinioServer{ObjectMeta: metav1.ObjectMeta{Name: "instrumented"}}
+	client, err := minioclient.Instrument(fake).BucketAdmin(context.TODO(), server)
+	if err != nil {
+		t.Fatalf("BucketAdmin: %v", err)
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	objects := 0
+	for object := range client.ListObjectsV2("mybucket", "", true, doneCh) {
+		if object.Err != nil {
+			t.Fatalf("ListObjectsV2: %v", object.Err)
+		}
+		objects++
+	}
+	if objects != 2 {
+		t.Errorf("listed %d objects, expected 2", objects)
+	}
+	// The request is observed once the channel is closed, after the objects are received
+	if requests, failures, durations := requestMetrics(t, "instrumented", "ListObjectsV2"); requests != 1 || failures != 0 || durations != 1 {
+		t.Errorf("requests %v, failures %v, durations %v, expected 1, 0, 1", requests, failures, durations)
+	}
+
+	fake.SetError("ListObjectsV2", errors.New("injected error"))
+	for range client.ListObjectsV2("mybucket", "", true, doneCh) {
+	}
+	if requests, failures, durations := requestMetrics(t, "instrumented", "ListObjectsV2"); requests != 2 || failures != 1 || durations != 2 {
+		t.Errorf("requests %v, failures %v, durations %v, expected 2, 1, 2", requests, failures, durations)
+	}
+}
@@ -0,0 +1,217 @@
+package minioclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go"
+	"github.com/minio/minio/pkg/madmin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+// defaultRequestTimeout is the timeout of requests to a MinioServer without requestTimeout
+const defaultRequestTimeout = 30 * time.Second
+
+// Pool cache Minio clients of each MinioServer, and share them between controllers.
+// Clients are rebuilt when the MinioServer spec or one of its Secrets changes, and dropped
+// when the MinioServer is deleted.
+type Pool struct {
+	client client.Client
+
+	mu      sync.Mutex
+	entries map[types.UID]*poolEntry
+}
+
+// poolEntry hold the clients of a MinioServer
+type poolEntry struct {
+	// name is the name of the MinioServer, a name being used by a single MinioServer at a time
+	name string
+	// version identify the MinioServer spec and Secrets used to build the clients
+	version   string
+	accessKey string
+	secretKey string
+	// httpTransport is the transport under transport, its idle connections are closed when the entry is dropped
+	httpTransport *http.Transport
+	transport     http.RoundTripper
+	minioClient   *minio.Client
+	adminClient   *madmin.AdminClient
+}
+
+// NewPool return an empty pool reading MinioServer Secrets with a Kubernetes client
+func NewPool(c client.Client) *Pool {
+	return &Pool{
+		client:  c,
+		entries: map[types.UID]*poolEntry{},
+	}
+}
+
+// Client return a Minio client connected to a MinioServer
+func (p *Pool) Client(ctx context.Context, server *miniov1alpha1.MinioServer) (*minio.Client, error) {
+	entry, err := p.get(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	return entry.minioClient, nil
+}
+
+// AdminClient return a Minio admin client connected to a MinioServer
+func (p *Pool) AdminClient(ctx context.Context, server *miniov1alpha1.MinioServer) (*madmin.AdminClient, error) {
+	entry, err := p.get(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	return entry.adminClient, nil
+}
+
+// Transport return the HTTP transport of a MinioServer
+func (p *Pool) Transport(ctx context.Context, server *miniov1alpha1.MinioServer) (http.RoundTripper, error) {
+	entry, err := p.get(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	return entry.transport, nil
+}
+
+// get return the up to date clients of a MinioServer, building them if needed
+func (p *Pool) get(ctx context.Context, server *miniov1alpha1.MinioServer) (*poolEntry, error) {
+	version, err := p.version(ctx, server)
+	if err != nil {
+		return nil, fmt.Errorf("p.version: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if entry, ok := p.entries[server.UID]; ok && entry.version == version {
+		return entry, nil
+	}
+
+	entry, err := p.newEntry(ctx, server)
+	if err != nil {
+		return nil, fmt.Errorf("p.newEntry: %w", err)
+	}
+	entry.name = server.Name
+	entry.version = version
+	// The clients of a previous version, or of a deleted MinioServer with the same name, are not used anymore
+	for uid, existing := range p.entries {
+		if uid == server.UID || existing.name == server.Name {
+			p.drop(uid)
+		}
+	}
+	p.entries[server.UID] = entry
+	return entry, nil
+}
+
+// Forget drop the clients of a deleted MinioServer
+func (p *Pool) Forget(server string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for uid, entry := range p.entries {
+		if entry.name == server {
+			p.drop(uid)
+		}
+	}
+}
+
+// drop remove the entry of a MinioServer UID and close its idle connections, p.mu must be locked.
+// Requests in progress with its clients are not interrupted.
+func (p *Pool) drop(uid types.UID) {
+	p.entries[uid].httpTransport.CloseIdleConnections()
+	delete(p.entries, uid)
+}
+
+// version return a string changing when the MinioServer spec or one of its Secrets changes.
+// Generation is used instead of resourceVersion, which also changes on each status update.
+func (p *Pool) version(ctx context.Context, server *miniov1alpha1.MinioServer) (string, error) {
+	versions := []string{strconv.FormatInt(server.Generation, 10)}
+	for _, ref := range secretsOf(&server.Spec) {
+		secret := &corev1.Secret{}
+		if err := p.client.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, secret); err != nil {
+			return "", fmt.Errorf("p.client.Get: %w", err)
+		}
+		versions = append(versions, secret.ResourceVersion)
+	}
+	return strings.Join(versions, "/"), nil
+}
+
+// newEntry build the clients of a MinioServer
+func (p *Pool) newEntry(ctx context.Context, server *miniov1alpha1.MinioServer) (*poolEntry, error) {
+	accessKey, secretKey, err := GetCredentials(ctx, p.client, server)
+	if err != nil {
+		return nil, fmt.Errorf("GetCredentials: %w", err)
+	}
+
+	transport, err := NewTransport(ctx, p.client, server)
+	if err != nil {
+		return nil, fmt.Errorf("NewTransport: %w", err)
+	}
+	timeout := defaultRequestTimeout
+	if server.Spec.RequestTimeout != nil {
+		timeout = server.Spec.RequestTimeout.Duration
+	}
+	roundTripper := &timeoutTransport{transport: transport, timeout: timeout}
+
+	minioClient, err := minio.New(server.Spec.GetHostname(), accessKey, secretKey, server.Spec.SSL)
+	if err != nil {
+		return nil, fmt.Errorf("minio.New: %w", err)
+	}
+	minioClient.SetCustomTransport(roundTripper)
+
+	// doc is https://github.com/minio/minio/tree/master/pkg/madmin
+	adminClient, err := madmin.New(server.Spec.GetHostname(), accessKey, secretKey, server.Spec.SSL)
+	if err != nil {
+		return nil, fmt.Errorf("madmin.New: %w", err)
+	}
+	adminClient.SetCustomTransport(roundTripper)
+
+	return &poolEntry{
+		accessKey:     accessKey,
+		secretKey:     secretKey,
+		httpTransport: transport,
+		transport:     roundTripper,
+		minioClient:   minioClient,
+		adminClient:   adminClient,
+	}, nil
+}
+
+// timeoutTransport cancel requests not completed, body included, before a timeout
+type timeoutTransport struct {
+	transport http.RoundTripper
+	timeout   time.Duration
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.transport.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelReadCloser{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelReadCloser cancel a request context when its response body is closed
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implements io.Closer
+func (c *cancelReadCloser) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
@@ -0,0 +1,69 @@
+package minioclient
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+func newTestServer(name string, uid types.UID) *miniov1alpha1.MinioServer {
+	return &miniov1alpha1.MinioServer{
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: uid, Generation: 1},
+		Spec: miniov1alpha1.MinioServerSpec{
+			Hostname:  "minio.example.com",
+			Port:      9000,
+			AccessKey: "admin",
+			SecretKey: "adminSecretKey",
+		},
+	}
+}
+
+// poolEntries return the UIDs of the MinioServers with clients in a pool
+func poolEntries(p *Pool) []types.UID {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	uids := []types.UID{}
+	for uid := range p.entries {
+		uids = append(uids, uid)
+	}
+	return uids
+}
+
+func TestPoolEviction(t *testing.T) {
+	p := NewPool(fakeclient.NewFakeClient())
+	server := newTestServer("test", "uid-1")
+	other := newTestServer("other", "uid-2")
+	for _, s := range []*miniov1alpha1.MinioServer{server, other} {
+		if _, err := p.Client(context.TODO(), s); err != nil {
+			t.Fatalf("p.Client: %v", err)
+		}
+	}
+
+	// A new version replaces the clients of the MinioServer
+	server.Generation = 2
+	if _, err := p.Client(context.TODO(), server); err != nil {
+		t.Fatalf("p.Client: %v", err)
+	}
+	if entries := poolEntries(p); len(entries) != 2 {
+		t.Errorf("pool entries are %v, expected one per MinioServer", entries)
+	}
+
+	// A MinioServer recreated with the same name replaces the clients of the deleted one
+	recreated := newTestServer("test", "uid-3")
+	if _, err := p.Client(context.TODO(), recreated); err != nil {
+		t.Fatalf("p.Client: %v", err)
+	}
+	if entries := poolEntries(p); len(entries) != 2 {
+		t.Errorf("pool entries are %v, expected one per MinioServer", entries)
+	}
+
+	p.Forget("test")
+	if entries := poolEntries(p); len(entries) != 1 || entries[0] != other.UID {
+		t.Errorf("pool entries are %v, expected only %s", entries, other.UID)
+	}
+}
@@ -0,0 +1,81 @@
+package minioclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/minio/minio-go"
+	"github.com/minio/minio-go/pkg/s3signer"
+	"github.com/minio/minio-go/pkg/s3utils"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+// defaultRegion is the region used to sign requests when the MinioServer region is unknown
+const defaultRegion = "us-east-1"
+
+// s3Request send a signed S3 request on a bucket or an object, for APIs missing in minio-go.
+// Errors returned by the server are minio.ErrorResponse.
+func (p *Pool) s3Request(ctx context.Context, server *miniov1alpha1.MinioServer, method, bucket, object string, query url.Values, body []byte) ([]byte, error) {
+	entry, err := p.get(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+
+	requestURL := server.Spec.GetEndpointURL() + "/" + bucket
+	if object != "" {
+		requestURL += "/" + s3utils.EncodePath(object)
+	}
+	if len(query) > 0 {
+		requestURL += "?" + s3utils.QueryEncode(query)
+	}
+	req, err := http.NewRequest(method, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequest: %w", err)
+	}
+	payloadHash := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+	if body != nil {
+		md5Sum := md5.Sum(body)
+		req.Header.Set("Content-Md5", base64.StdEncoding.EncodeToString(md5Sum[:]))
+	}
+
+	region := server.Status.Region
+	if region == "" {
+		region = defaultRegion
+	}
+	req = s3signer.SignV4(*req, entry.accessKey, entry.secretKey, "", region)
+
+	httpClient := &http.Client{Transport: entry.transport}
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("httpClient.Do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ioutil.ReadAll: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		errResponse := minio.ErrorResponse{}
+		if xmlErr := xml.Unmarshal(respBody, &errResponse); xmlErr != nil {
+			errResponse.Code = resp.Status
+			errResponse.Message = string(respBody)
+		}
+		errResponse.BucketName = bucket
+		errResponse.StatusCode = resp.StatusCode
+		return nil, errResponse
+	}
+	return respBody, nil
+}
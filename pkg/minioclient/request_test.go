@@ -0,0 +1,79 @@
+package minioclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/minio/minio-go"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestS3Request(t *testing.T) {
+	stub := newS3Stub("mybucket")
+	httpServer := httptest.NewServer(stub)
+	defer httpServer.Close()
+	server, secret := newStubServer(t, httpServer)
+	p := NewPool(fakeclient.NewFakeClient(secret))
+
+	// Requests are signed with the credentials of the Secret
+	if _, err := p.s3Request(context.TODO(), server, http.MethodPut, "mybucket", "", nil, []byte("<Tagging></Tagging>")); err == nil {
+		t.Error("request without query succeeded, expected NotImplemented")
+	}
+	if _, err := p.s3Request(context.TODO(), server, http.MethodGet, "mybucket", "", map[string][]string{"versioning": {""}}, nil); err != nil {
+		t.Errorf("p.s3Request: %v", err)
+	}
+
+	// Errors of the server are minio.ErrorResponse
+	_, err := p.s3Request(context.TODO(), server, http.MethodGet, "missing", "", map[string][]string{"versioning": {""}}, nil)
+	errResponse := minio.ErrorResponse{}
+	if !errors.As(err, &errResponse) {
+		t.Fatalf("error is %v, expected a minio.ErrorResponse", err)
+	}
+	if errResponse.Code != "NoSuchBucket" || errResponse.StatusCode != http.StatusNotFound || errResponse.BucketName != "missing" {
+		t.Errorf("error is %+v, expected NoSuchBucket", errResponse)
+	}
+
+	// Requests signed with other credentials are denied
+	secret.Data["secretKey"] = []byte("otherSecretKey")
+	secret.Data["accessKey"] = []byte("otherAccessKey")
+	other := NewPool(fakeclient.NewFakeClient(secret))
+	if _, err := other.s3Request(context.TODO(), server, http.MethodGet, "mybucket", "", map[string][]string{"versioning": {""}}, nil); errorCode(err) != "InvalidAccessKeyId" {
+		t.Errorf("error is %v, expected InvalidAccessKeyId", err)
+	}
+}
+
+func TestPoolClients(t *testing.T) {
+	stub := newS3Stub("mybucket")
+	stub.sizes["mybucket"] = 1024
+	httpServer := httptest.NewServer(stub)
+	defer httpServer.Close()
+	server, secret := newStubServer(t, httpServer)
+	p := NewPool(fakeclient.NewFakeClient(secret))
+
+	bucketAdmin, err := p.BucketAdmin(context.TODO(), server)
+	if err != nil {
+		t.Fatalf("p.BucketAdmin: %v", err)
+	}
+	if exists, err := bucketAdmin.BucketExists("mybucket"); err != nil || !exists {
+		t.Errorf("bucket exists is %v, error %v, expected true", exists, err)
+	}
+
+	serverAdmin, err := p.ServerAdmin(context.TODO(), server)
+	if err != nil {
+		t.Fatalf("p.ServerAdmin: %v", err)
+	}
+	usage, err := serverAdmin.DataUsageInfo()
+	if err != nil {
+		t.Fatalf("serverAdmin.DataUsageInfo: %v", err)
+	}
+	if usage.BucketsSizes["mybucket"] != 1024 {
+		t.Errorf("bucket sizes are %v, expected mybucket of 1024 bytes", usage.BucketsSizes)
+	}
+
+	if err := p.CheckLiveness(context.TODO(), server); err != nil {
+		t.Errorf("p.CheckLiveness: %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+package minioclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+// Credentials accepted by s3Stub
+const (
+	stubAccessKey = "stubAccessKey"
+	stubSecretKey = "stubSecretKey"
+)
+
+// s3Stub is an HTTP stand-in of the S3 and admin APIs of a Minio server used by the pool. It checks
+// requests are signed with its credentials, and keeps the tags, versioning and object versions of buckets.
+type s3Stub struct {
+	mu sync.Mutex
+	// notImplemented make the bucket APIs respond like a Minio server without tagging and versioning
+	notImplemented bool
+	buckets        map[string]*stubBucket
+	sizes          map[string]uint64
+}
+
+// stubBucket is the state of a bucket of s3Stub
+type stubBucket struct {
+	tags       map[string]string
+	versioning string
+	versions   []ObjectVersion
+}
+
+// newS3Stub return a stub with the buckets
+func newS3Stub(buckets ...string) *s3Stub {
+	s := &s3Stub{buckets: map[string]*stubBucket{}, sizes: map[string]uint64{}}
+	for _, bucket := range buckets {
+		s.buckets[bucket] = &stubBucket{}
+	}
+	return s
+}
+
+// newStubServer return a MinioServer connected to an httptest server, with credentials in a Secret
+func newStubServer(t *testing.T, server *httptest.Server) (*miniov1alpha1.MinioServer, *corev1.Secret) {
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.SplitHostPort: %v", err)
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatalf("strconv.Atoi: %v", err)
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "minio", Name: "credentials"},
+		Data: map[string][]byte{
+			"accessKey": []byte(stubAccessKey),
+			"secretKey": []byte(stubSecretKey),
+		},
+	}
+	return &miniov1alpha1.MinioServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "stub", UID: "stub-uid", Generation: 1},
+		Spec: miniov1alpha1.MinioServerSpec{
+			Hostname:             host,
+			Port:                 portNumber,
+			CredentialsSecretRef: &miniov1alpha1.CredentialsSecretReference{Namespace: "minio", Name: "credentials"},
+		},
+	}, secret
+}
+
+// writeError write an S3 error response
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>%s</Code><Message>%s</Message></Error>`, code, code)
+}
+
+// writeXML write a successful XML response
+func writeXML(w http.ResponseWriter, v interface{}) {
+	body, err := xml.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "InternalError")
+		return
+	}
+	_, _ = w.Write(body)
+}
+
+// ServeHTTP implements http.Handler
+func (s *s3Stub) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.URL.Path == "/minio/health/live" {
+		return
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody")
+		return
+	}
+	if !strings.HasPrefix(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential="+stubAccessKey+"/") {
+		writeError(w, http.StatusForbidden, "InvalidAccessKeyId")
+		return
+	}
+	if hash := req.Header.Get("X-Amz-Content-Sha256"); hash != "UNSIGNED-PAYLOAD" {
+		payloadHash := sha256.Sum256(body)
+		if hash != hex.EncodeToString(payloadHash[:]) {
+			writeError(w, http.StatusBadRequest, "XAmzContentSHA256Mismatch")
+			return
+		}
+	}
+
+	if req.URL.Path == "/minio/admin/v2/datausageinfo" {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"bucketsSizes": s.sizes})
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 2)
+	bucket, ok := s.buckets[parts[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	query := req.URL.Query()
+	_, isLocation := query["location"]
+	_, isTagging := query["tagging"]
+	_, isVersioning := query["versioning"]
+	_, isVersions := query["versions"]
+	if s.notImplemented && (isTagging || isVersioning || isVersions) {
+		writeError(w, http.StatusNotImplemented, "NotImplemented")
+		return
+	}
+
+	switch {
+	case len(parts) == 2 && req.Method == http.MethodDelete:
+		s.deleteVersion(w, bucket, parts[1], query.Get("versionId"))
+	case isLocation && req.Method == http.MethodGet:
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/"></LocationConstraint>`)
+	case isTagging && req.Method == http.MethodGet:
+		if len(bucket.tags) == 0 {
+			writeError(w, http.StatusNotFound, "NoSuchTagSet")
+			return
+		}
+		document := tagging{}
+		for key, value := range bucket.tags {
+			document.TagSet = append(document.TagSet, tag{Key: key, Value: value})
+		}
+		writeXML(w, document)
+	case isTagging && req.Method == http.MethodPut:
+		document := tagging{}
+		if err := xml.Unmarshal(body, &document); err != nil {
+			writeError(w, http.StatusBadRequest, "MalformedXML")
+			return
+		}
+		bucket.tags = map[string]string{}
+		for _, t := range document.TagSet {
+			bucket.tags[t.Key] = t.Value
+		}
+	case isVersioning && req.Method == http.MethodGet:
+		writeXML(w, versioningConfiguration{Status: bucket.versioning})
+	case isVersioning && req.Method == http.MethodPut:
+		config := versioningConfiguration{}
+		if err := xml.Unmarshal(body, &config); err != nil {
+			writeError(w, http.StatusBadRequest, "MalformedXML")
+			return
+		}
+		bucket.versioning = config.Status
+	case isVersions && req.Method == http.MethodGet:
+		maxKeys, err := strconv.Atoi(query.Get("max-keys"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidArgument")
+			return
+		}
+		result := listVersionsResult{}
+		for i, version := range bucket.versions {
+			if i == maxKeys {
+				break
+			}
+			if version.VersionID == "marker" {
+				result.DeleteMarkers = append(result.DeleteMarkers, version)
+			} else {
+				result.Versions = append(result.Versions, version)
+			}
+		}
+		writeXML(w, result)
+	case req.Method == http.MethodHead:
+	default:
+		writeError(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+// deleteVersion remove a version of an object of a bucket
+func (s *s3Stub) deleteVersion(w http.ResponseWriter, bucket *stubBucket, key, versionID string) {
+	for i, version := range bucket.versions {
+		if version.Key == key && version.VersionID == versionID {
+			bucket.versions = append(bucket.versions[:i], bucket.versions[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "NoSuchVersion")
+}
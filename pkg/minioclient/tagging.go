@@ -0,0 +1,73 @@
+package minioclient
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+// ErrTaggingNotSupported is returned when the Minio server doesn't implement bucket tagging
+var ErrTaggingNotSupported = errors.New("bucket tagging is not supported by the Minio server")
+
+// tagging is the S3 bucket tagging document
+type tagging struct {
+	XMLName xml.Name `xml:"Tagging"`
+	TagSet  []tag    `xml:"TagSet>Tag"`
+}
+
+type tag struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+// GetBucketTags return the tags of a bucket
+func (p *Pool) GetBucketTags(ctx context.Context, server *miniov1alpha1.MinioServer, bucket string) (map[string]string, error) {
+	body, err := p.s3Request(ctx, server, http.MethodGet, bucket, "", url.Values{"tagging": {""}}, nil)
+	if err != nil {
+		if isNotImplemented(err) {
+			return nil, ErrTaggingNotSupported
+		}
+		if errorCode(err) == "NoSuchTagSet" {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("p.s3Request: %w", err)
+	}
+
+	document := tagging{}
+	if err = xml.Unmarshal(body, &document); err != nil {
+		return nil, fmt.Errorf("xml.Unmarshal: %w", err)
+	}
+	tags := map[string]string{}
+	for _, t := range document.TagSet {
+		tags[t.Key] = t.Value
+	}
+	return tags, nil
+}
+
+// SetBucketTags replace the tags of a bucket
+func (p *Pool) SetBucketTags(ctx context.Context, server *miniov1alpha1.MinioServer, bucket string, tags map[string]string) error {
+	document := tagging{}
+	for key, value := range tags {
+		document.TagSet = append(document.TagSet, tag{Key: key, Value: value})
+	}
+	sort.Slice(document.TagSet, func(i, j int) bool { return document.TagSet[i].Key < document.TagSet[j].Key })
+
+	body, err := xml.Marshal(document)
+	if err != nil {
+		return fmt.Errorf("xml.Marshal: %w", err)
+	}
+
+	if _, err = p.s3Request(ctx, server, http.MethodPut, bucket, "", url.Values{"tagging": {""}}, body); err != nil {
+		if isNotImplemented(err) {
+			return ErrTaggingNotSupported
+		}
+		return fmt.Errorf("p.s3Request: %w", err)
+	}
+	return nil
+}
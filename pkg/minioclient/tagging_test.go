@@ -0,0 +1,46 @@
+package minioclient
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestBucketTags(t *testing.T) {
+	stub := newS3Stub("mybucket")
+	httpServer := httptest.NewServer(stub)
+	defer httpServer.Close()
+	server, secret := newStubServer(t, httpServer)
+	p := NewPool(fakeclient.NewFakeClient(secret))
+
+	// A bucket without tags has no tag set
+	tags, err := p.GetBucketTags(context.TODO(), server, "mybucket")
+	if err != nil {
+		t.Fatalf("p.GetBucketTags: %v", err)
+	}
+	if len(tags) != 0 {
+		t.Errorf("tags are %v, expected none", tags)
+	}
+
+	expected := map[string]string{"team": "robotics", "env": "production"}
+	if err := p.SetBucketTags(context.TODO(), server, "mybucket", expected); err != nil {
+		t.Fatalf("p.SetBucketTags: %v", err)
+	}
+	if tags, err = p.GetBucketTags(context.TODO(), server, "mybucket"); err != nil {
+		t.Fatalf("p.GetBucketTags: %v", err)
+	}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("tags are %v, expected %v", tags, expected)
+	}
+
+	stub.notImplemented = true
+	if _, err := p.GetBucketTags(context.TODO(), server, "mybucket"); err != ErrTaggingNotSupported {
+		t.Errorf("error is %v, expected ErrTaggingNotSupported", err)
+	}
+	if err := p.SetBucketTags(context.TODO(), server, "mybucket", expected); err != ErrTaggingNotSupported {
+		t.Errorf("error is %v, expected ErrTaggingNotSupported", err)
+	}
+}
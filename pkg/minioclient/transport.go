@@ -0,0 +1,84 @@
+package minioclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+const defaultCAKey = "ca.crt"
+
+// NewTransport return a HTTP transport configured with the TLS options of a MinioServer
+func NewTransport(ctx context.Context, c client.Client, server *miniov1alpha1.MinioServer) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if server.Spec.TLS == nil {
+		return transport, nil
+	}
+
+	tlsConfig, err := newTLSConfig(ctx, c, server.Spec.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("newTLSConfig: %w", err)
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// newTLSConfig build a TLS client configuration from MinioServer TLS options
+func newTLSConfig(ctx context.Context, c client.Client, options *miniov1alpha1.TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: options.InsecureSkipVerify,
+		ServerName:         options.ServerName,
+	}
+
+	if len(options.CABundle) != 0 || options.CASecretRef != nil {
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("x509.SystemCertPool: %w", err)
+		}
+
+		if len(options.CABundle) != 0 && !rootCAs.AppendCertsFromPEM([]byte(options.CABundle)) {
+			return nil, fmt.Errorf("no certificate found in caBundle")
+		}
+
+		if ref := options.CASecretRef; ref != nil {
+			key := ref.Key
+			if len(key) == 0 {
+				key = defaultCAKey
+			}
+			caBundle, err := getSecretKey(ctx, c, ref.Namespace, ref.Name, key)
+			if err != nil {
+				return nil, fmt.Errorf("getSecretKey: %w", err)
+			}
+			if !rootCAs.AppendCertsFromPEM(caBundle) {
+				return nil, fmt.Errorf("no certificate found in secret %s/%s", ref.Namespace, ref.Name)
+			}
+		}
+
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	if ref := options.ClientCertSecretRef; ref != nil {
+		cert, err := getSecretKey(ctx, c, ref.Namespace, ref.Name, corev1.TLSCertKey)
+		if err != nil {
+			return nil, fmt.Errorf("getSecretKey: %w", err)
+		}
+		key, err := getSecretKey(ctx, c, ref.Namespace, ref.Name, corev1.TLSPrivateKeyKey)
+		if err != nil {
+			return nil, fmt.Errorf("getSecretKey: %w", err)
+		}
+		clientCert, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("tls.X509KeyPair: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	return tlsConfig, nil
+}
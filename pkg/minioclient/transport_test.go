@@ -0,0 +1,50 @@
+package minioclient
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http/httptest"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+func TestTransportTLS(t *testing.T) {
+	httpServer := httptest.NewTLSServer(newS3Stub("mybucket"))
+	defer httpServer.Close()
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: httpServer.Certificate().Raw})
+	caSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "minio", Name: "ca"},
+		Data:       map[string][]byte{"ca.crt": caBundle},
+	}
+
+	tests := []struct {
+		name  string
+		tls   *miniov1alpha1.TLSConfig
+		valid bool
+	}{
+		{name: "system CAs", tls: nil, valid: false},
+		{name: "caBundle", tls: &miniov1alpha1.TLSConfig{CABundle: string(caBundle)}, valid: true},
+		{name: "caSecretRef", tls: &miniov1alpha1.TLSConfig{CASecretRef: &miniov1alpha1.SecretKeyReference{Namespace: "minio", Name: "ca"}}, valid: true},
+		{name: "insecureSkipVerify", tls: &miniov1alpha1.TLSConfig{InsecureSkipVerify: true}, valid: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, secret := newStubServer(t, httpServer)
+			server.Spec.SSL = true
+			server.Spec.TLS = test.tls
+			p := NewPool(fakeclient.NewFakeClient(secret, caSecret))
+
+			if err := p.CheckLiveness(context.TODO(), server); (err == nil) != test.valid {
+				t.Errorf("p.CheckLiveness error is %v, expected valid %v", err, test.valid)
+			}
+			if _, err := p.GetBucketVersioning(context.TODO(), server, "mybucket"); (err == nil) != test.valid {
+				t.Errorf("p.GetBucketVersioning error is %v, expected valid %v", err, test.valid)
+			}
+		})
+	}
+}
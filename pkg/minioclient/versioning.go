@@ -0,0 +1,75 @@
+package minioclient
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/minio/minio-go"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+// ErrVersioningNotSupported is returned when the Minio server doesn't implement bucket versioning
+var ErrVersioningNotSupported = errors.New("bucket versioning is not supported by the Minio server")
+
+// versioningConfiguration is the S3 bucket versioning document
+type versioningConfiguration struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ VersioningConfiguration"`
+	Status  string   `xml:"Status,omitempty"`
+}
+
+// GetBucketVersioning return the versioning state of a bucket, empty if versioning was never enabled.
+// minio-go doesn't implement bucket versioning, the S3 API is called directly.
+func (p *Pool) GetBucketVersioning(ctx context.Context, server *miniov1alpha1.MinioServer, bucket string) (string, error) {
+	body, err := p.s3Request(ctx, server, http.MethodGet, bucket, "", url.Values{"versioning": {""}}, nil)
+	if err != nil {
+		if isNotImplemented(err) {
+			return "", ErrVersioningNotSupported
+		}
+		return "", fmt.Errorf("p.s3Request: %w", err)
+	}
+
+	config := versioningConfiguration{}
+	if err = xml.Unmarshal(body, &config); err != nil {
+		return "", fmt.Errorf("xml.Unmarshal: %w", err)
+	}
+	return config.Status, nil
+}
+
+// SetBucketVersioning set the versioning state of a bucket, Enabled or Suspended
+func (p *Pool) SetBucketVersioning(ctx context.Context, server *miniov1alpha1.MinioServer, bucket, status string) error {
+	body, err := xml.Marshal(versioningConfiguration{Status: status})
+	if err != nil {
+		return fmt.Errorf("xml.Marshal: %w", err)
+	}
+
+	if _, err = p.s3Request(ctx, server, http.MethodPut, bucket, "", url.Values{"versioning": {""}}, body); err != nil {
+		if isNotImplemented(err) {
+			return ErrVersioningNotSupported
+		}
+		return fmt.Errorf("p.s3Request: %w", err)
+	}
+	return nil
+}
+
+// isNotImplemented return true if an S3 error is about an API not implemented by the server
+func isNotImplemented(err error) bool {
+	errResponse := minio.ErrorResponse{}
+	if !errors.As(err, &errResponse) {
+		return false
+	}
+	return errResponse.Code == "NotImplemented" || errResponse.StatusCode == http.StatusNotImplemented
+}
+
+// errorCode return the code of an S3 error, empty if it is not an S3 error
+func errorCode(err error) string {
+	errResponse := minio.ErrorResponse{}
+	if !errors.As(err, &errResponse) {
+		return ""
+	}
+	return errResponse.Code
+}
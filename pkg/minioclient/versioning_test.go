@@ -0,0 +1,87 @@
+package minioclient
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestBucketVersioning(t *testing.T) {
+	stub := newS3Stub("mybucket")
+	httpServer := httptest.NewServer(stub)
+	defer httpServer.Close()
+	server, secret := newStubServer(t, httpServer)
+	p := NewPool(fakeclient.NewFakeClient(secret))
+
+	// Versioning of a bucket where it was never enabled is empty
+	status, err := p.GetBucketVersioning(context.TODO(), server, "mybucket")
+	if err != nil {
+		t.Fatalf("p.GetBucketVersioning: %v", err)
+	}
+	if status != "" {
+		t.Errorf("versioning is %q, expected empty", status)
+	}
+
+	for _, expected := range []string{"Enabled", "Suspended"} {
+		if err := p.SetBucketVersioning(context.TODO(), server, "mybucket", expected); err != nil {
+			t.Fatalf("p.SetBucketVersioning: %v", err)
+		}
+		if status, err = p.GetBucketVersioning(context.TODO(), server, "mybucket"); err != nil {
+			t.Fatalf("p.GetBucketVersioning: %v", err)
+		}
+		if status != expected {
+			t.Errorf("versioning is %q, expected %q", status, expected)
+		}
+	}
+
+	stub.notImplemented = true
+	if _, err := p.GetBucketVersioning(context.TODO(), server, "mybucket"); err != ErrVersioningNotSupported {
+		t.Errorf("error is %v, expected ErrVersioningNotSupported", err)
+	}
+	if err := p.SetBucketVersioning(context.TODO(), server, "mybucket", "Enabled"); err != ErrVersioningNotSupported {
+		t.Errorf("error is %v, expected ErrVersioningNotSupported", err)
+	}
+}
+
+func TestObjectVersions(t *testing.T) {
+	stub := newS3Stub("mybucket")
+	stub.buckets["mybucket"].versions = []ObjectVersion{
+		{Key: "dir/object one", VersionID: "v1"},
+		{Key: "dir/object one", VersionID: "marker"},
+		{Key: "other", VersionID: "v2"},
+	}
+	httpServer := httptest.NewServer(stub)
+	defer httpServer.Close()
+	server, secret := newStubServer(t, httpServer)
+	p := NewPool(fakeclient.NewFakeClient(secret))
+
+	// Versions are listed before delete markers
+	versions, err := p.ListObjectVersions(context.TODO(), server, "mybucket", 2)
+	if err != nil {
+		t.Fatalf("p.ListObjectVersions: %v", err)
+	}
+	expected := []ObjectVersion{{Key: "dir/object one", VersionID: "v1"}, {Key: "dir/object one", VersionID: "marker"}}
+	if !reflect.DeepEqual(versions, expected) {
+		t.Errorf("versions are %v, expected %v", versions, expected)
+	}
+
+	// Keys are escaped in the path
+	for _, version := range versions {
+		if err := p.RemoveObjectVersion(context.TODO(), server, "mybucket", version); err != nil {
+			t.Fatalf("p.RemoveObjectVersion: %v", err)
+		}
+	}
+	if versions, err = p.ListObjectVersions(context.TODO(), server, "mybucket", 10); err != nil {
+		t.Fatalf("p.ListObjectVersions: %v", err)
+	}
+	if expected := []ObjectVersion{{Key: "other", VersionID: "v2"}}; !reflect.DeepEqual(versions, expected) {
+		t.Errorf("versions are %v, expected %v", versions, expected)
+	}
+
+	if err := p.RemoveObjectVersion(context.TODO(), server, "mybucket", ObjectVersion{Key: "other", VersionID: "missing"}); errorCode(err) != "NoSuchVersion" {
+		t.Errorf("error is %v, expected NoSuchVersion", err)
+	}
+}
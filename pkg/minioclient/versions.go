@@ -0,0 +1,55 @@
+package minioclient
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+// ObjectVersion is a version of an object, or a delete marker
+type ObjectVersion struct {
+	Key       string `xml:"Key"`
+	VersionID string `xml:"VersionId"`
+}
+
+// listVersionsResult is the S3 response listing object versions
+type listVersionsResult struct {
+	XMLName       xml.Name        `xml:"ListVersionsResult"`
+	Versions      []ObjectVersion `xml:"Version"`
+	DeleteMarkers []ObjectVersion `xml:"DeleteMarker"`
+}
+
+// ListObjectVersions return up to maxKeys versions and delete markers of the objects of a bucket
+func (p *Pool) ListObjectVersions(ctx context.Context, server *miniov1alpha1.MinioServer, bucket string, maxKeys int) ([]ObjectVersion, error) {
+	body, err := p.s3Request(ctx, server, http.MethodGet, bucket, "", url.Values{
+		"versions": {""},
+		"max-keys": {strconv.Itoa(maxKeys)},
+	}, nil)
+	if err != nil {
+		if isNotImplemented(err) {
+			return nil, ErrVersioningNotSupported
+		}
+		return nil, fmt.Errorf("p.s3Request: %w", err)
+	}
+
+	result := listVersionsResult{}
+	if err = xml.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("xml.Unmarshal: %w", err)
+	}
+	return append(result.Versions, result.DeleteMarkers...), nil
+}
+
+// RemoveObjectVersion remove a version of an object, or a delete marker
+func (p *Pool) RemoveObjectVersion(ctx context.Context, server *miniov1alpha1.MinioServer, bucket string, version ObjectVersion) error {
+	if _, err := p.s3Request(ctx, server, http.MethodDelete, bucket, version.Key, url.Values{
+		"versionId": {version.VersionID},
+	}, nil); err != nil {
+		return fmt.Errorf("p.s3Request: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// listKeys are statement keys which value can be a string or a list of strings
+var listKeys = []string{"Action", "NotAction", "Resource", "NotResource"}
+
+// principalKeys are statement keys holding a principal
+var principalKeys = []string{"Principal", "NotPrincipal"}
+
+// document is a policy document with normalized statements
+type document struct {
+	Version    string
+	Statements []string
+}
+
+// parse return a policy document with each statement as canonical JSON, sorted
+func parse(policy string) (*document, error) {
+	doc := &document{Version: Version, Statements: []string{}}
+	if strings.TrimSpace(policy) == "" {
+		return doc, nil
+	}
+
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(policy), &raw); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	if version, ok := raw["Version"].(string); ok {
+		doc.Version = version
+	}
+
+	// Statement can be a single statement or a list of statements
+	statements, ok := raw["Statement"].([]interface{})
+	if !ok && raw["Statement"] != nil {
+		statements = []interface{}{raw["Statement"]}
+	}
+	for _, item := range statements {
+		statement, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid statement %v", item)
+		}
+		normalized, err := json.Marshal(normalizeStatement(statement))
+		if err != nil {
+			return nil, fmt.Errorf("json.Marshal: %w", err)
+		}
+		doc.Statements = append(doc.Statements, string(normalized))
+	}
+	sort.Strings(doc.Statements)
+	return doc, nil
+}
+
+// normalizeStatement return a statement with sorted lists instead of strings, without empty Sid
+func normalizeStatement(statement map[string]interface{}) map[string]interface{} {
+	if sid, ok := statement["Sid"].(string); ok && sid == "" {
+		delete(statement, "Sid")
+	}
+	for _, key := range listKeys {
+		if value, ok := statement[key]; ok {
+			statement[key] = toSortedList(value)
+		}
+	}
+	for _, key := range principalKeys {
+		switch principal := statement[key].(type) {
+		case string:
+			// "*" is the same as {"AWS": "*"}
+			statement[key] = map[string]interface{}{"AWS": toSortedList(principal)}
+		case map[string]interface{}:
+			for name, value := range principal {
+				principal[name] = toSortedList(value)
+			}
+		}
+	}
+	if conditions, ok := statement["Condition"].(map[string]interface{}); ok {
+		for operator, value := range conditions {
+			keys, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for name, values := range keys {
+				keys[name] = toSortedList(values)
+			}
+			conditions[operator] = keys
+		}
+	}
+	return statement
+}
+
+// toSortedList return a string or a list of values as a sorted list without duplicates
+func toSortedList(value interface{}) interface{} {
+	items := []interface{}{value}
+	if list, ok := value.([]interface{}); ok {
+		items = list
+	}
+
+	values := []string{}
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			// Keep values which are not strings unchanged
+			return value
+		}
+		values = append(values, s)
+	}
+	sort.Strings(values)
+
+	result := []string{}
+	for i, s := range values {
+		if i == 0 || s != values[i-1] {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// Normalize return the canonical JSON of a policy document, independent of formatting,
+// keys and statements order, and of actions, resources and principals given as string or list
+func Normalize(policy string) (string, error) {
+	doc, err := parse(policy)
+	if err != nil {
+		return "", err
+	}
+	if len(doc.Statements) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf(`{"Statement":[%s],"Version":%q}`, strings.Join(doc.Statements, ","), doc.Version), nil
+}
+
+// Equal return true if two policy documents are semantically equal
+func Equal(a, b string) (bool, error) {
+	normalizedA, err := Normalize(a)
+	if err != nil {
+		return false, fmt.Errorf("Normalize: %w", err)
+	}
+	normalizedB, err := Normalize(b)
+	if err != nil {
+		return false, fmt.Errorf("Normalize: %w", err)
+	}
+	return normalizedA == normalizedB, nil
+}
+
+// Diff return the statements removed from and added to a policy document, one per line
+// prefixed by - or +, for logging
+func Diff(from, to string) (string, error) {
+	docFrom, err := parse(from)
+	if err != nil {
+		return "", fmt.Errorf("parse: %w", err)
+	}
+	docTo, err := parse(to)
+	if err != nil {
+		return "", fmt.Errorf("parse: %w", err)
+	}
+
+	lines := []string{}
+	if docFrom.Version != docTo.Version {
+		lines = append(lines, fmt.Sprintf("- Version %s", docFrom.Version), fmt.Sprintf("+ Version %s", docTo.Version))
+	}
+	for _, statement := range docFrom.Statements {
+		if !contains(docTo.Statements, statement) {
+			lines = append(lines, "- "+statement)
+		}
+	}
+	for _, statement := range docTo.Statements {
+		if !contains(docFrom.Statements, statement) {
+			lines = append(lines, "+ "+statement)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// contains return true if a string is in a sorted list of strings
+func contains(list []string, s string) bool {
+	i := sort.SearchStrings(list, s)
+	return i < len(list) && list[i] == s
+}
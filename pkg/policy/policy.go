@@ -0,0 +1,67 @@
+// Package policy handle Minio policy documents
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	iampolicy "github.com/minio/minio/pkg/iam/policy"
+	bucketpolicy "github.com/minio/minio/pkg/policy"
+)
+
+// Version is the version of generated policy documents
+const Version = "2012-10-17"
+
+// Merge return a policy document with the statements of all documents
+func Merge(documents ...string) (string, error) {
+	statements := []json.RawMessage{}
+	for _, document := range documents {
+		var doc struct {
+			Statement json.RawMessage `json:"Statement"`
+		}
+		if err := json.Unmarshal([]byte(document), &doc); err != nil {
+			return "", fmt.Errorf("json.Unmarshal: %w", err)
+		}
+
+		// Statement can be a single statement or a list of statements
+		if bytes.HasPrefix(bytes.TrimSpace(doc.Statement), []byte("[")) {
+			list := []json.RawMessage{}
+			if err := json.Unmarshal(doc.Statement, &list); err != nil {
+				return "", fmt.Errorf("json.Unmarshal: %w", err)
+			}
+			statements = append(statements, list...)
+		} else if len(doc.Statement) != 0 {
+			statements = append(statements, doc.Statement)
+		}
+	}
+
+	merged, err := json.Marshal(struct {
+		Version   string            `json:"Version"`
+		Statement []json.RawMessage `json:"Statement"`
+	}{
+		Version:   Version,
+		Statement: statements,
+	})
+	if err != nil {
+		return "", fmt.Errorf("json.Marshal: %w", err)
+	}
+	return string(merged), nil
+}
+
+// ValidateCanned return an error if a canned policy document is invalid
+func ValidateCanned(document string) error {
+	if _, err := iampolicy.ParseConfig(strings.NewReader(document)); err != nil {
+		return fmt.Errorf("iampolicy.ParseConfig: %w", err)
+	}
+	return nil
+}
+
+// ValidateBucket return an error if a bucket policy document is invalid for a bucket
+func ValidateBucket(document, bucket string) error {
+	if _, err := bucketpolicy.ParseConfig(strings.NewReader(document), bucket); err != nil {
+		return fmt.Errorf("bucketpolicy.ParseConfig: %w", err)
+	}
+	return nil
+}
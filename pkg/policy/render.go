@@ -0,0 +1,211 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+// resourcePrefix is the prefix of S3 resources ARN
+const resourcePrefix = "arn:aws:s3:::"
+
+// presetActions are the bucket and object actions granted by policy presets
+var presetActions = map[miniov1alpha1.PolicyPresetAccess]struct {
+	bucket []string
+	object []string
+}{
+	miniov1alpha1.PolicyPresetReadOnly: {
+		bucket: []string{"s3:GetBucketLocation", "s3:ListBucket"},
+		object: []string{"s3:GetObject"},
+	},
+	miniov1alpha1.PolicyPresetReadWrite: {
+		bucket: []string{"s3:GetBucketLocation", "s3:ListBucket", "s3:ListBucketMultipartUploads"},
+		object: []string{"s3:AbortMultipartUpload", "s3:DeleteObject", "s3:GetObject", "s3:ListMultipartUploadParts", "s3:PutObject"},
+	},
+	miniov1alpha1.PolicyPresetWriteOnly: {
+		bucket: []string{"s3:GetBucketLocation", "s3:ListBucketMultipartUploads"},
+		object: []string{"s3:AbortMultipartUpload", "s3:ListMultipartUploadParts", "s3:PutObject"},
+	},
+}
+
+// statement is a statement of a rendered policy document
+type statement struct {
+	Effect    string                         `json:"Effect"`
+	Principal map[string][]string            `json:"Principal,omitempty"`
+	Action    []string                       `json:"Action"`
+	Resource  []string                       `json:"Resource"`
+	Condition map[string]map[string][]string `json:"Condition,omitempty"`
+}
+
+// RenderCanned return the canned policy document of policy rules and presets, empty without any
+func RenderCanned(rules []miniov1alpha1.PolicyRule, presets []miniov1alpha1.PolicyPreset) (string, error) {
+	return render(rules, presets, "", false)
+}
+
+// RenderBucket return the bucket policy document of policy rules and presets, empty without any.
+// Rules and presets without buckets apply to the bucket, rules without principals apply to everyone.
+func RenderBucket(bucket string, rules []miniov1alpha1.PolicyRule, presets []miniov1alpha1.PolicyPreset) (string, error) {
+	return render(rules, presets, bucket, true)
+}
+
+// render return the policy document of policy rules and presets, with principals for bucket policies
+func render(rules []miniov1alpha1.PolicyRule, presets []miniov1alpha1.PolicyPreset, defaultBucket string, withPrincipal bool) (string, error) {
+	if len(rules) == 0 && len(presets) == 0 {
+		return "", nil
+	}
+
+	statements := []statement{}
+	for i, r := range rules {
+		if len(r.Actions) == 0 {
+			return "", fmt.Errorf("policy rule %d without actions", i)
+		}
+		effect := r.Effect
+		if effect == "" {
+			effect = miniov1alpha1.PolicyEffectAllow
+		}
+		if effect != miniov1alpha1.PolicyEffectAllow && effect != miniov1alpha1.PolicyEffectDeny {
+			return "", fmt.Errorf("policy rule %d with invalid effect %q", i, effect)
+		}
+
+		buckets := r.Buckets
+		if len(buckets) == 0 {
+			if defaultBucket != "" {
+				buckets = []string{defaultBucket}
+			} else {
+				buckets = []string{"*"}
+			}
+		}
+		s := statement{
+			Effect:   string(effect),
+			Action:   r.Actions,
+			Resource: append(bucketResources(buckets), objectResources(buckets, r.Prefixes)...),
+		}
+
+		if withPrincipal {
+			principals := r.Principals
+			if len(principals) == 0 {
+				principals = []string{"*"}
+			}
+			s.Principal = map[string][]string{"AWS": principals}
+		}
+
+		for _, c := range r.Conditions {
+			if c.Operator == "" || c.Key == "" {
+				return "", fmt.Errorf("policy rule %d with condition without operator or key", i)
+			}
+			if s.Condition == nil {
+				s.Condition = map[string]map[string][]string{}
+			}
+			if s.Condition[c.Operator] == nil {
+				s.Condition[c.Operator] = map[string][]string{}
+			}
+			s.Condition[c.Operator][c.Key] = append(s.Condition[c.Operator][c.Key], c.Values...)
+		}
+		statements = append(statements, s)
+	}
+
+	for i, p := range presets {
+		actions, ok := presetActions[p.Access]
+		if !ok {
+			return "", fmt.Errorf("policy preset %d with invalid access %q", i, p.Access)
+		}
+		buckets := p.Buckets
+		if len(buckets) == 0 {
+			if defaultBucket == "" {
+				return "", fmt.Errorf("policy preset %d without buckets", i)
+			}
+			buckets = []string{defaultBucket}
+		}
+
+		presetStatements := []statement{}
+		bucketActions := actions.bucket
+		// Listing is restricted to the prefixes with a condition, as bucket resources can't be
+		if len(p.Prefixes) != 0 {
+			bucketActions = []string{}
+			for _, action := range actions.bucket {
+				if action != "s3:ListBucket" {
+					bucketActions = append(bucketActions, action)
+				}
+			}
+			if len(bucketActions) != len(actions.bucket) {
+				patterns := []string{}
+				for _, prefix := range p.Prefixes {
+					patterns = append(patterns, prefix+"*")
+				}
+				presetStatements = append(presetStatements, statement{
+					Effect:    string(miniov1alpha1.PolicyEffectAllow),
+					Action:    []string{"s3:ListBucket"},
+					Resource:  bucketResources(buckets),
+					Condition: map[string]map[string][]string{"StringLike": {"s3:prefix": patterns}},
+				})
+			}
+		}
+		presetStatements = append(presetStatements, statement{
+			Effect:   string(miniov1alpha1.PolicyEffectAllow),
+			Action:   bucketActions,
+			Resource: bucketResources(buckets),
+		}, statement{
+			Effect:   string(miniov1alpha1.PolicyEffectAllow),
+			Action:   actions.object,
+			Resource: objectResources(buckets, p.Prefixes),
+		})
+		for _, s := range presetStatements {
+			if withPrincipal {
+				s.Principal = map[string][]string{"AWS": {"*"}}
+			}
+			statements = append(statements, s)
+		}
+	}
+
+	document, err := json.Marshal(struct {
+		Version   string      `json:"Version"`
+		Statement []statement `json:"Statement"`
+	}{
+		Version:   Version,
+		Statement: statements,
+	})
+	if err != nil {
+		return "", fmt.Errorf("json.Marshal: %w", err)
+	}
+	return string(document), nil
+}
+
+// Combine return the policy document merging an inline document and a rendered document,
+// either of them being possibly empty
+func Combine(inline, rendered string) (string, error) {
+	switch {
+	case rendered == "":
+		return inline, nil
+	case inline == "":
+		return rendered, nil
+	}
+	merged, err := Merge(inline, rendered)
+	if err != nil {
+		return "", fmt.Errorf("Merge: %w", err)
+	}
+	return merged, nil
+}
+
+// bucketResources return the ARN of buckets
+func bucketResources(buckets []string) []string {
+	resources := []string{}
+	for _, bucket := range buckets {
+		resources = append(resources, resourcePrefix+bucket)
+	}
+	return resources
+}
+
+// objectResources return the ARN of objects with prefixes in buckets, all objects without prefixes
+func objectResources(buckets, prefixes []string) []string {
+	if len(prefixes) == 0 {
+		prefixes = []string{""}
+	}
+	resources := []string{}
+	for _, bucket := range buckets {
+		for _, prefix := range prefixes {
+			resources = append(resources, resourcePrefix+bucket+"/"+prefix+"*")
+		}
+	}
+	return resources
+}
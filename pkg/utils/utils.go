@@ -1,5 +1,10 @@
 package utils
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
 // Contains return true if a string is in a list of string
 func Contains(list []string, s string) bool {
 	for _, v := range list {
@@ -19,3 +24,9 @@ func Remove(list []string, s string) []string {
 	}
 	return list
 }
+
+// Hash return the hex encoded SHA-256 of a string
+func Hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
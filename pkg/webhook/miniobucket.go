@@ -0,0 +1,66 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/minio/minio-go/pkg/s3utils"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/lifecycle"
+	"github.com/robotinfra/minio-resources-operator/pkg/policy"
+)
+
+// validateMinioBucket check the bucket name, policy, policy rules and lifecycle, and that name and server are not changed
+func validateMinioBucket(ctx context.Context, c client.Client, obj, old runtime.Object) field.ErrorList {
+	bucket := obj.(*miniov1alpha1.MinioBucket)
+	specPath := field.NewPath("spec")
+	errs := field.ErrorList{}
+
+	oldBucket := &miniov1alpha1.MinioBucket{}
+	if old != nil {
+		oldBucket = old.(*miniov1alpha1.MinioBucket)
+		if skipUpdate(bucket, bucket.Spec, oldBucket.Spec) {
+			return nil
+		}
+	}
+
+	if changed(old, bucket.Spec.Name, oldBucket.Spec.Name) {
+		if err := s3utils.CheckValidBucketNameStrict(bucket.Spec.Name); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("name"), bucket.Spec.Name, err.Error()))
+		}
+	}
+
+	if bucket.Spec.Policy != "" && changed(old, bucket.Spec.Policy, oldBucket.Spec.Policy) {
+		if err := policy.ValidateBucket(bucket.Spec.Policy, bucket.Spec.Name); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("policy"), bucket.Spec.Policy, err.Error()))
+		}
+	}
+
+	if changed(old, bucket.Spec.PolicyRules, oldBucket.Spec.PolicyRules) || changed(old, bucket.Spec.PolicyPresets, oldBucket.Spec.PolicyPresets) {
+		rendered, err := policy.RenderBucket(bucket.Spec.Name, bucket.Spec.PolicyRules, bucket.Spec.PolicyPresets)
+		if err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("policyRules"), "", err.Error()))
+		} else if rendered != "" {
+			if err := policy.ValidateBucket(rendered, bucket.Spec.Name); err != nil {
+				errs = append(errs, field.Invalid(specPath.Child("policyRules"), rendered, err.Error()))
+			}
+		}
+	}
+
+	if bucket.Spec.Lifecycle != nil && changed(old, bucket.Spec.Lifecycle, oldBucket.Spec.Lifecycle) {
+		if _, err := lifecycle.Render(bucket.Spec.Lifecycle.Rules); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("lifecycle", "rules"), "", err.Error()))
+		}
+	}
+
+	if old == nil {
+		return append(errs, validateServer(ctx, c, specPath.Child("server"), bucket.Spec.Server)...)
+	}
+	errs = append(errs, apivalidation.ValidateImmutableField(bucket.Spec.Name, oldBucket.Spec.Name, specPath.Child("name"))...)
+	errs = append(errs, apivalidation.ValidateImmutableField(bucket.Spec.Server, oldBucket.Spec.Server, specPath.Child("server"))...)
+	return errs
+}
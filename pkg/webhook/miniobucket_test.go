@@ -0,0 +1,134 @@
+package webhook
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+// newBucket return a valid MinioBucket, modified by mutate
+func newBucket(mutate func(*miniov1alpha1.MinioBucket)) *miniov1alpha1.MinioBucket {
+	bucket := &miniov1alpha1.MinioBucket{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "bucket"},
+		Spec:       miniov1alpha1.MinioBucketSpec{Server: "test", Name: "mybucket"},
+	}
+	mutate(bucket)
+	return bucket
+}
+
+func TestValidateMinioBucket(t *testing.T) {
+	none := func(*miniov1alpha1.MinioBucket) {}
+	// legacy is a bucket created before its name was validated
+	legacy := func(b *miniov1alpha1.MinioBucket) { b.Spec.Name = "My_Bucket" }
+	now := metav1.Now()
+	runValidationTests(t, newTestClient(t), validateMinioBucket, []validationTest{
+		{name: "valid", obj: newBucket(none)},
+		{
+			name:   "name with uppercase",
+			obj:    newBucket(func(b *miniov1alpha1.MinioBucket) { b.Spec.Name = "MyBucket" }),
+			fields: []string{"spec.name"},
+		},
+		{
+			name:   "name too short",
+			obj:    newBucket(func(b *miniov1alpha1.MinioBucket) { b.Spec.Name = "ab" }),
+			fields: []string{"spec.name"},
+		},
+		{
+			name:   "name as an IP address",
+			obj:    newBucket(func(b *miniov1alpha1.MinioBucket) { b.Spec.Name = "192.168.1.1" }),
+			fields: []string{"spec.name"},
+		},
+		{
+			name: "policy",
+			obj: newBucket(func(b *miniov1alpha1.MinioBucket) {
+				b.Spec.Policy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::mybucket/*"]}]}`
+			}),
+		},
+		{
+			name:   "policy not JSON",
+			obj:    newBucket(func(b *miniov1alpha1.MinioBucket) { b.Spec.Policy = "{" }),
+			fields: []string{"spec.policy"},
+		},
+		{
+			name: "policy rules",
+			obj: newBucket(func(b *miniov1alpha1.MinioBucket) {
+				b.Spec.PolicyRules = []miniov1alpha1.PolicyRule{{Actions: []string{"s3:GetObject"}}}
+			}),
+		},
+		{
+			name: "policy rule without actions",
+			obj: newBucket(func(b *miniov1alpha1.MinioBucket) {
+				b.Spec.PolicyRules = []miniov1alpha1.PolicyRule{{Prefixes: []string{"public/"}}}
+			}),
+			fields: []string{"spec.policyRules"},
+		},
+		{
+			name: "lifecycle rule without id",
+			obj: newBucket(func(b *miniov1alpha1.MinioBucket) {
+				b.Spec.Lifecycle = &miniov1alpha1.Lifecycle{Rules: []miniov1alpha1.LifecycleRule{{NoncurrentVersionExpirationDays: 7}}}
+			}),
+			fields: []string{"spec.lifecycle.rules"},
+		},
+		{
+			name:   "missing server",
+			obj:    newBucket(func(b *miniov1alpha1.MinioBucket) { b.Spec.Server = "missing" }),
+			fields: []string{"spec.server"},
+		},
+		{
+			name: "update",
+			obj:  newBucket(func(b *miniov1alpha1.MinioBucket) { b.Spec.Policy = "" }),
+			old:  newBucket(none),
+		},
+		{
+			name: "finalizer added to a legacy bucket",
+			obj: newBucket(func(b *miniov1alpha1.MinioBucket) {
+				legacy(b)
+				b.Finalizers = []string{"finalizer.bucket.minio.robotinfra.com"}
+			}),
+			old: newBucket(legacy),
+		},
+		{
+			name: "finalizer removed from a deleted legacy bucket",
+			obj: newBucket(func(b *miniov1alpha1.MinioBucket) {
+				legacy(b)
+				b.DeletionTimestamp = &now
+			}),
+			old: newBucket(func(b *miniov1alpha1.MinioBucket) {
+				legacy(b)
+				b.DeletionTimestamp = &now
+				b.Finalizers = []string{"finalizer.bucket.minio.robotinfra.com"}
+			}),
+		},
+		{
+			name: "lifecycle changed on a legacy bucket",
+			obj: newBucket(func(b *miniov1alpha1.MinioBucket) {
+				legacy(b)
+				b.Spec.Lifecycle = &miniov1alpha1.Lifecycle{Rules: []miniov1alpha1.LifecycleRule{{ID: "versions", NoncurrentVersionExpirationDays: 7}}}
+			}),
+			old: newBucket(legacy),
+		},
+		{
+			name: "invalid lifecycle on a legacy bucket",
+			obj: newBucket(func(b *miniov1alpha1.MinioBucket) {
+				legacy(b)
+				b.Spec.Lifecycle = &miniov1alpha1.Lifecycle{Rules: []miniov1alpha1.LifecycleRule{{NoncurrentVersionExpirationDays: 7}}}
+			}),
+			old:    newBucket(legacy),
+			fields: []string{"spec.lifecycle.rules"},
+		},
+		{
+			name:   "name changed",
+			obj:    newBucket(func(b *miniov1alpha1.MinioBucket) { b.Spec.Name = "otherbucket" }),
+			old:    newBucket(none),
+			fields: []string{"spec.name"},
+		},
+		{
+			name:   "server changed",
+			obj:    newBucket(func(b *miniov1alpha1.MinioBucket) { b.Spec.Server = "other" }),
+			old:    newBucket(none),
+			fields: []string{"spec.server"},
+		},
+	})
+}
@@ -0,0 +1,38 @@
+package webhook
+
+import (
+	"context"
+
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+// validateMinioGroup check that name and server are not changed
+func validateMinioGroup(ctx context.Context, c client.Client, obj, old runtime.Object) field.ErrorList {
+	group := obj.(*miniov1alpha1.MinioGroup)
+	specPath := field.NewPath("spec")
+	errs := field.ErrorList{}
+
+	oldGroup := &miniov1alpha1.MinioGroup{}
+	if old != nil {
+		oldGroup = old.(*miniov1alpha1.MinioGroup)
+		if skipUpdate(group, group.Spec, oldGroup.Spec) {
+			return nil
+		}
+	}
+
+	if group.Spec.Name == "" && changed(old, group.Spec.Name, oldGroup.Spec.Name) {
+		errs = append(errs, field.Required(specPath.Child("name"), ""))
+	}
+
+	if old == nil {
+		return append(errs, validateServer(ctx, c, specPath.Child("server"), group.Spec.Server)...)
+	}
+	errs = append(errs, apivalidation.ValidateImmutableField(group.Spec.Name, oldGroup.Spec.Name, specPath.Child("name"))...)
+	errs = append(errs, apivalidation.ValidateImmutableField(group.Spec.Server, oldGroup.Spec.Server, specPath.Child("server"))...)
+	return errs
+}
@@ -0,0 +1,48 @@
+package webhook
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+// newGroup return a valid MinioGroup, modified by mutate
+func newGroup(mutate func(*miniov1alpha1.MinioGroup)) *miniov1alpha1.MinioGroup {
+	group := &miniov1alpha1.MinioGroup{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "group"},
+		Spec:       miniov1alpha1.MinioGroupSpec{Server: "test", Name: "mygroup"},
+	}
+	mutate(group)
+	return group
+}
+
+func TestValidateMinioGroup(t *testing.T) {
+	none := func(*miniov1alpha1.MinioGroup) {}
+	runValidationTests(t, newTestClient(t), validateMinioGroup, []validationTest{
+		{name: "valid", obj: newGroup(none)},
+		{
+			name:   "name missing",
+			obj:    newGroup(func(g *miniov1alpha1.MinioGroup) { g.Spec.Name = "" }),
+			fields: []string{"spec.name"},
+		},
+		{
+			name:   "missing server",
+			obj:    newGroup(func(g *miniov1alpha1.MinioGroup) { g.Spec.Server = "missing" }),
+			fields: []string{"spec.server"},
+		},
+		{
+			name:   "name changed",
+			obj:    newGroup(func(g *miniov1alpha1.MinioGroup) { g.Spec.Name = "othergroup" }),
+			old:    newGroup(none),
+			fields: []string{"spec.name"},
+		},
+		{
+			name:   "server changed",
+			obj:    newGroup(func(g *miniov1alpha1.MinioGroup) { g.Spec.Server = "other" }),
+			old:    newGroup(none),
+			fields: []string{"spec.server"},
+		},
+	})
+}
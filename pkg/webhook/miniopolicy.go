@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/policy"
+)
+
+// validateMinioPolicy check the name and policy document, and that name and server are not changed.
+// Built-in Minio policies and names starting with the prefix of MinioUser generated policies are reserved, and
+// commas separate policy names attached to a user.
+func validateMinioPolicy(ctx context.Context, c client.Client, obj, old runtime.Object) field.ErrorList {
+	minioPolicy := obj.(*miniov1alpha1.MinioPolicy)
+	specPath := field.NewPath("spec")
+	errs := field.ErrorList{}
+
+	oldPolicy := &miniov1alpha1.MinioPolicy{}
+	if old != nil {
+		oldPolicy = old.(*miniov1alpha1.MinioPolicy)
+		if skipUpdate(minioPolicy, minioPolicy.Spec, oldPolicy.Spec) {
+			return nil
+		}
+	}
+
+	switch name := minioPolicy.Spec.Name; {
+	case !changed(old, name, oldPolicy.Spec.Name):
+	case name == "":
+		errs = append(errs, field.Required(specPath.Child("name"), ""))
+	case miniov1alpha1.IsBuiltinPolicy(name):
+		errs = append(errs, field.Invalid(specPath.Child("name"), name, "must not be a built-in Minio policy"))
+	case strings.HasPrefix(name, miniov1alpha1.GeneratedPolicyPrefix):
+		errs = append(errs, field.Invalid(specPath.Child("name"), name, fmt.Sprintf("must not start with %s, reserved to MinioUser", miniov1alpha1.GeneratedPolicyPrefix)))
+	case strings.Contains(name, ","):
+		errs = append(errs, field.Invalid(specPath.Child("name"), name, "must not contain commas"))
+	}
+	if changed(old, minioPolicy.Spec.Policy, oldPolicy.Spec.Policy) {
+		if err := policy.ValidateCanned(minioPolicy.Spec.Policy); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("policy"), minioPolicy.Spec.Policy, err.Error()))
+		}
+	}
+
+	if old == nil {
+		return append(errs, validateServer(ctx, c, specPath.Child("server"), minioPolicy.Spec.Server)...)
+	}
+	errs = append(errs, apivalidation.ValidateImmutableField(minioPolicy.Spec.Name, oldPolicy.Spec.Name, specPath.Child("name"))...)
+	errs = append(errs, apivalidation.ValidateImmutableField(minioPolicy.Spec.Server, oldPolicy.Spec.Server, specPath.Child("server"))...)
+	return errs
+}
@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+// newPolicy return a valid MinioPolicy, modified by mutate
+func newPolicy(mutate func(*miniov1alpha1.MinioPolicy)) *miniov1alpha1.MinioPolicy {
+	minioPolicy := &miniov1alpha1.MinioPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "policy"},
+		Spec: miniov1alpha1.MinioPolicySpec{
+			Server: "test",
+			Name:   "mybucket-read",
+			Policy: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:GetObject"],"Resource":["arn:aws:s3:::mybucket/*"]}]}`,
+		},
+	}
+	mutate(minioPolicy)
+	return minioPolicy
+}
+
+func TestValidateMinioPolicy(t *testing.T) {
+	none := func(*miniov1alpha1.MinioPolicy) {}
+	runValidationTests(t, newTestClient(t), validateMinioPolicy, []validationTest{
+		{name: "valid", obj: newPolicy(none)},
+		{
+			name:   "name missing",
+			obj:    newPolicy(func(p *miniov1alpha1.MinioPolicy) { p.Spec.Name = "" }),
+			fields: []string{"spec.name"},
+		},
+		{
+			name:   "name reserved to MinioUser",
+			obj:    newPolicy(func(p *miniov1alpha1.MinioPolicy) { p.Spec.Name = miniov1alpha1.GeneratedPolicyPrefix + "myUsername" }),
+			fields: []string{"spec.name"},
+		},
+		{
+			name:   "built-in Minio policy",
+			obj:    newPolicy(func(p *miniov1alpha1.MinioPolicy) { p.Spec.Name = "readwrite" }),
+			fields: []string{"spec.name"},
+		},
+		{
+			name:   "name with a comma",
+			obj:    newPolicy(func(p *miniov1alpha1.MinioPolicy) { p.Spec.Name = "read,write" }),
+			fields: []string{"spec.name"},
+		},
+		{
+			name:   "policy not JSON",
+			obj:    newPolicy(func(p *miniov1alpha1.MinioPolicy) { p.Spec.Policy = "{" }),
+			fields: []string{"spec.policy"},
+		},
+		{
+			name:   "policy missing",
+			obj:    newPolicy(func(p *miniov1alpha1.MinioPolicy) { p.Spec.Policy = "" }),
+			fields: []string{"spec.policy"},
+		},
+		{
+			name:   "missing server",
+			obj:    newPolicy(func(p *miniov1alpha1.MinioPolicy) { p.Spec.Server = "missing" }),
+			fields: []string{"spec.server"},
+		},
+		{
+			name:   "name changed",
+			obj:    newPolicy(func(p *miniov1alpha1.MinioPolicy) { p.Spec.Name = "mybucket-write" }),
+			old:    newPolicy(none),
+			fields: []string{"spec.name"},
+		},
+		{
+			name:   "server changed",
+			obj:    newPolicy(func(p *miniov1alpha1.MinioPolicy) { p.Spec.Server = "other" }),
+			old:    newPolicy(none),
+			fields: []string{"spec.server"},
+		},
+	})
+}
@@ -0,0 +1,129 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+	"github.com/robotinfra/minio-resources-operator/pkg/policy"
+)
+
+// Lengths of keys accepted by Minio
+const (
+	accessKeyMinLength = 3
+	accessKeyMaxLength = 20
+	secretKeyMinLength = 8
+	secretKeyMaxLength = 40
+)
+
+// validateMinioUser check the keys length, connection Secret, policy and policy rules, and that access key and
+// server are not changed
+func validateMinioUser(ctx context.Context, c client.Client, obj, old runtime.Object) field.ErrorList {
+	user := obj.(*miniov1alpha1.MinioUser)
+	specPath := field.NewPath("spec")
+	errs := field.ErrorList{}
+
+	oldUser := &miniov1alpha1.MinioUser{}
+	if old != nil {
+		oldUser = old.(*miniov1alpha1.MinioUser)
+		if skipUpdate(user, user.Spec, oldUser.Spec) {
+			return nil
+		}
+	}
+
+	if changed(old, user.Spec.AccessKey, oldUser.Spec.AccessKey) {
+		errs = append(errs, validateLength(specPath.Child("accessKey"), user.Spec.AccessKey, user.Spec.AccessKey, accessKeyMinLength, accessKeyMaxLength)...)
+	}
+	keyChanged := changed(old, user.Spec.SecretKey, oldUser.Spec.SecretKey) || changed(old, user.Spec.SecretKeyRef, oldUser.Spec.SecretKeyRef)
+	if keyChanged {
+		if user.Spec.SecretKey != "" {
+			errs = append(errs, validateLength(specPath.Child("secretKey"), "", user.Spec.SecretKey, secretKeyMinLength, secretKeyMaxLength)...)
+		} else if ref := user.Spec.SecretKeyRef; ref != nil {
+			errs = append(errs, validateSecretKeyRef(ctx, c, specPath.Child("secretKeyRef"), user.Namespace, ref)...)
+		}
+	}
+
+	if ref := user.Spec.WriteConnectionSecretToRef; ref != nil && (keyChanged || changed(old, ref.Name, connectionSecretName(oldUser))) {
+		switch {
+		case user.Spec.SecretKey == "" && user.Spec.SecretKeyRef != nil && ref.Name == user.Spec.SecretKeyRef.Name:
+			errs = append(errs, field.Invalid(specPath.Child("writeConnectionSecretToRef", "name"), ref.Name, "must not be the secretKeyRef Secret"))
+		case user.Spec.SecretKey == "" && user.Spec.SecretKeyRef == nil && ref.Name == user.GetGeneratedSecretName():
+			errs = append(errs, field.Invalid(specPath.Child("writeConnectionSecretToRef", "name"), ref.Name, "must not be the generated secret key Secret"))
+		}
+	}
+
+	if user.Spec.Policy != "" && changed(old, user.Spec.Policy, oldUser.Spec.Policy) {
+		if err := policy.ValidateCanned(user.Spec.Policy); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("policy"), user.Spec.Policy, err.Error()))
+		}
+	}
+
+	if changed(old, user.Spec.Policies, oldUser.Spec.Policies) {
+		for i, name := range user.Spec.Policies {
+			if name == "" || strings.Contains(name, ",") {
+				errs = append(errs, field.Invalid(specPath.Child("policies").Index(i), name, "must be a policy name, without commas"))
+			}
+		}
+	}
+
+	if changed(old, user.Spec.PolicyRules, oldUser.Spec.PolicyRules) || changed(old, user.Spec.PolicyPresets, oldUser.Spec.PolicyPresets) {
+		rendered, err := policy.RenderCanned(user.Spec.PolicyRules, user.Spec.PolicyPresets)
+		if err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("policyRules"), "", err.Error()))
+		} else if rendered != "" {
+			if err := policy.ValidateCanned(rendered); err != nil {
+				errs = append(errs, field.Invalid(specPath.Child("policyRules"), rendered, err.Error()))
+			}
+		}
+	}
+
+	if old == nil {
+		return append(errs, validateServer(ctx, c, specPath.Child("server"), user.Spec.Server)...)
+	}
+	errs = append(errs, apivalidation.ValidateImmutableField(user.Spec.AccessKey, oldUser.Spec.AccessKey, specPath.Child("accessKey"))...)
+	errs = append(errs, apivalidation.ValidateImmutableField(user.Spec.Server, oldUser.Spec.Server, specPath.Child("server"))...)
+	return errs
+}
+
+// connectionSecretName return the name of the connection Secret of a MinioUser, empty if it has none
+func connectionSecretName(user *miniov1alpha1.MinioUser) string {
+	if user.Spec.WriteConnectionSecretToRef == nil {
+		return ""
+	}
+	return user.Spec.WriteConnectionSecretToRef.Name
+}
+
+// validateLength return an error if a value is not between min and max characters.
+// shown is the value reported in the error, to not leak secrets.
+func validateLength(path *field.Path, shown, value string, min, max int) field.ErrorList {
+	if len(value) < min || len(value) > max {
+		return field.ErrorList{field.Invalid(path, shown, fmt.Sprintf("must be between %d and %d characters", min, max))}
+	}
+	return nil
+}
+
+// validateSecretKeyRef return an error if the secret key of a referenced Secret has an invalid length.
+// A missing Secret or key is accepted, it can be created after the MinioUser.
+func validateSecretKeyRef(ctx context.Context, c client.Client, path *field.Path, namespace string, ref *corev1.SecretKeySelector) field.ErrorList {
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return field.ErrorList{field.InternalError(path, err)}
+	}
+	secretKey, ok := secret.Data[ref.Key]
+	if !ok {
+		return nil
+	}
+	return validateLength(path, "", string(secretKey), secretKeyMinLength, secretKeyMaxLength)
+}
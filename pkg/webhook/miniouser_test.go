@@ -0,0 +1,184 @@
+package webhook
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+// newUser return a valid MinioUser, modified by mutate
+func newUser(mutate func(*miniov1alpha1.MinioUser)) *miniov1alpha1.MinioUser {
+	user := &miniov1alpha1.MinioUser{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "user"},
+		Spec:       miniov1alpha1.MinioUserSpec{Server: "test", AccessKey: "myUsername", SecretKey: "mySecurePassword"},
+	}
+	mutate(user)
+	return user
+}
+
+// withSecretKeyRef set the secret key of a MinioUser to a reference to the key of a Secret
+func withSecretKeyRef(name, key string) func(*miniov1alpha1.MinioUser) {
+	return func(u *miniov1alpha1.MinioUser) {
+		u.Spec.SecretKey = ""
+		u.Spec.SecretKeyRef = &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: key}
+	}
+}
+
+func TestValidateMinioUser(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "credentials"},
+		Data: map[string][]byte{
+			"secretKey": []byte("mySecurePassword"),
+			"short":     []byte("short"),
+			"long":      []byte(strings.Repeat("a", 41)),
+		},
+	}
+	none := func(*miniov1alpha1.MinioUser) {}
+	// legacy is a user created before the length of its secret key was validated
+	legacy := withSecretKeyRef("credentials", "short")
+	now := metav1.Now()
+	runValidationTests(t, newTestClient(t, secret), validateMinioUser, []validationTest{
+		{name: "valid", obj: newUser(none)},
+		{
+			name: "keys of maximum length",
+			obj: newUser(func(u *miniov1alpha1.MinioUser) {
+				u.Spec.AccessKey = strings.Repeat("a", 20)
+				u.Spec.SecretKey = strings.Repeat("a", 40)
+			}),
+		},
+		{
+			name:   "access key too short",
+			obj:    newUser(func(u *miniov1alpha1.MinioUser) { u.Spec.AccessKey = "ab" }),
+			fields: []string{"spec.accessKey"},
+		},
+		{
+			name:   "access key too long",
+			obj:    newUser(func(u *miniov1alpha1.MinioUser) { u.Spec.AccessKey = strings.Repeat("a", 21) }),
+			fields: []string{"spec.accessKey"},
+		},
+		{
+			name:   "secret key too short",
+			obj:    newUser(func(u *miniov1alpha1.MinioUser) { u.Spec.SecretKey = "short" }),
+			fields: []string{"spec.secretKey"},
+		},
+		{
+			name:   "secret key too long",
+			obj:    newUser(func(u *miniov1alpha1.MinioUser) { u.Spec.SecretKey = strings.Repeat("a", 41) }),
+			fields: []string{"spec.secretKey"},
+		},
+		{name: "generated secret key", obj: newUser(func(u *miniov1alpha1.MinioUser) { u.Spec.SecretKey = "" })},
+		{name: "secretKeyRef", obj: newUser(withSecretKeyRef("credentials", "secretKey"))},
+		{name: "secretKeyRef to a missing Secret", obj: newUser(withSecretKeyRef("missing", "secretKey"))},
+		{name: "secretKeyRef to a missing key", obj: newUser(withSecretKeyRef("credentials", "missing"))},
+		{
+			name:   "secretKeyRef too short",
+			obj:    newUser(withSecretKeyRef("credentials", "short")),
+			fields: []string{"spec.secretKeyRef"},
+		},
+		{
+			name:   "secretKeyRef too long",
+			obj:    newUser(withSecretKeyRef("credentials", "long")),
+			fields: []string{"spec.secretKeyRef"},
+		},
+		{
+			name: "connection Secret",
+			obj: newUser(func(u *miniov1alpha1.MinioUser) {
+				withSecretKeyRef("credentials", "secretKey")(u)
+				u.Spec.WriteConnectionSecretToRef = &miniov1alpha1.ConnectionSecret{Name: "connection"}
+			}),
+		},
+		{
+			name: "connection Secret to the secretKeyRef Secret",
+			obj: newUser(func(u *miniov1alpha1.MinioUser) {
+				withSecretKeyRef("credentials", "secretKey")(u)
+				u.Spec.WriteConnectionSecretToRef = &miniov1alpha1.ConnectionSecret{Name: "credentials"}
+			}),
+			fields: []string{"spec.writeConnectionSecretToRef.name"},
+		},
+		{
+			name: "connection Secret to the generated secret key Secret",
+			obj: newUser(func(u *miniov1alpha1.MinioUser) {
+				u.Spec.SecretKey = ""
+				u.Spec.WriteConnectionSecretToRef = &miniov1alpha1.ConnectionSecret{Name: "user-minio-user"}
+			}),
+			fields: []string{"spec.writeConnectionSecretToRef.name"},
+		},
+		{
+			name:   "policy not JSON",
+			obj:    newUser(func(u *miniov1alpha1.MinioUser) { u.Spec.Policy = "{" }),
+			fields: []string{"spec.policy"},
+		},
+		{
+			name:   "policies with a comma",
+			obj:    newUser(func(u *miniov1alpha1.MinioUser) { u.Spec.Policies = []string{"readonly", "a,b", ""} }),
+			fields: []string{"spec.policies[1]", "spec.policies[2]"},
+		},
+		{
+			name: "policy rule without actions",
+			obj: newUser(func(u *miniov1alpha1.MinioUser) {
+				u.Spec.PolicyRules = []miniov1alpha1.PolicyRule{{Buckets: []string{"mybucket"}}}
+			}),
+			fields: []string{"spec.policyRules"},
+		},
+		{
+			name:   "missing server",
+			obj:    newUser(func(u *miniov1alpha1.MinioUser) { u.Spec.Server = "missing" }),
+			fields: []string{"spec.server"},
+		},
+		{
+			name: "update",
+			obj:  newUser(func(u *miniov1alpha1.MinioUser) { u.Spec.Policies = []string{"readonly"} }),
+			old:  newUser(none),
+		},
+		{
+			name: "finalizer added to a legacy user",
+			obj: newUser(func(u *miniov1alpha1.MinioUser) {
+				legacy(u)
+				u.Finalizers = []string{"finalizer.user.minio.robotinfra.com"}
+			}),
+			old: newUser(legacy),
+		},
+		{
+			name: "finalizer removed from a deleted legacy user",
+			obj: newUser(func(u *miniov1alpha1.MinioUser) {
+				legacy(u)
+				u.DeletionTimestamp = &now
+			}),
+			old: newUser(func(u *miniov1alpha1.MinioUser) {
+				legacy(u)
+				u.DeletionTimestamp = &now
+				u.Finalizers = []string{"finalizer.user.minio.robotinfra.com"}
+			}),
+		},
+		{
+			name: "policies changed on a legacy user",
+			obj: newUser(func(u *miniov1alpha1.MinioUser) {
+				legacy(u)
+				u.Spec.Policies = []string{"readonly"}
+			}),
+			old: newUser(legacy),
+		},
+		{
+			name:   "secretKeyRef changed to a short key",
+			obj:    newUser(legacy),
+			old:    newUser(withSecretKeyRef("credentials", "secretKey")),
+			fields: []string{"spec.secretKeyRef"},
+		},
+		{
+			name:   "access key changed",
+			obj:    newUser(func(u *miniov1alpha1.MinioUser) { u.Spec.AccessKey = "otherUsername" }),
+			old:    newUser(none),
+			fields: []string{"spec.accessKey"},
+		},
+		{
+			name:   "server changed",
+			obj:    newUser(func(u *miniov1alpha1.MinioUser) { u.Spec.Server = "other" }),
+			old:    newUser(none),
+			fields: []string{"spec.server"},
+		},
+	})
+}
@@ -0,0 +1,127 @@
+// Package webhook validate Minio resources on admission
+package webhook
+
+import (
+	"context"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+var log = logf.Log.WithName("webhook")
+
+// Paths of the validating webhooks
+const (
+	MinioBucketPath = "/validate-minio-robotinfra-com-v1alpha1-miniobucket"
+	MinioUserPath   = "/validate-minio-robotinfra-com-v1alpha1-miniouser"
+	MinioPolicyPath = "/validate-minio-robotinfra-com-v1alpha1-miniopolicy"
+	MinioGroupPath  = "/validate-minio-robotinfra-com-v1alpha1-miniogroup"
+)
+
+// AddToManager register the validating webhooks on the Manager webhook server
+func AddToManager(mgr manager.Manager) error {
+	server := mgr.GetWebhookServer()
+	server.Register(MinioBucketPath, &admission.Webhook{Handler: &validator{
+		newObject: func() runtime.Object { return &miniov1alpha1.MinioBucket{} },
+		validate:  validateMinioBucket,
+	}})
+	server.Register(MinioUserPath, &admission.Webhook{Handler: &validator{
+		newObject: func() runtime.Object { return &miniov1alpha1.MinioUser{} },
+		validate:  validateMinioUser,
+	}})
+	server.Register(MinioPolicyPath, &admission.Webhook{Handler: &validator{
+		newObject: func() runtime.Object { return &miniov1alpha1.MinioPolicy{} },
+		validate:  validateMinioPolicy,
+	}})
+	server.Register(MinioGroupPath, &admission.Webhook{Handler: &validator{
+		newObject: func() runtime.Object { return &miniov1alpha1.MinioGroup{} },
+		validate:  validateMinioGroup,
+	}})
+	return nil
+}
+
+// validateFunc return the errors of an object. old is nil on creation.
+// On update, only the changed fields are validated, so objects created before a validation was added can
+// still be updated and deleted.
+type validateFunc func(ctx context.Context, c client.Client, obj, old runtime.Object) field.ErrorList
+
+// validator is an admission handler validating the creation and update of an object
+type validator struct {
+	client    client.Client
+	decoder   *admission.Decoder
+	newObject func() runtime.Object
+	validate  validateFunc
+}
+
+// InjectClient implements inject.Client
+func (v *validator) InjectClient(c client.Client) error {
+	v.client = c
+	return nil
+}
+
+// InjectDecoder implements admission.DecoderInjector
+func (v *validator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Handle implements admission.Handler
+func (v *validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	reqLogger := log.WithValues("Request.Kind", req.Kind.Kind, "Request.Namespace", req.Namespace, "Request.Name", req.Name)
+
+	obj := v.newObject()
+	if err := v.decoder.Decode(req, obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	var old runtime.Object
+	if req.Operation == admissionv1beta1.Update {
+		old = v.newObject()
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
+	if errs := v.validate(ctx, v.client, obj, old); len(errs) > 0 {
+		reqLogger.Info("Denied", "errors", errs.ToAggregate().Error())
+		return admission.Denied(errs.ToAggregate().Error())
+	}
+	return admission.Allowed("")
+}
+
+// validateServer return an error if a MinioServer doesn't exist
+func validateServer(ctx context.Context, c client.Client, path *field.Path, name string) field.ErrorList {
+	if name == "" {
+		return field.ErrorList{field.Required(path, "")}
+	}
+	if err := c.Get(ctx, types.NamespacedName{Name: name}, &miniov1alpha1.MinioServer{}); err != nil {
+		if errors.IsNotFound(err) {
+			return field.ErrorList{field.NotFound(path, name)}
+		}
+		return field.ErrorList{field.InternalError(path, err)}
+	}
+	return nil
+}
+
+// skipUpdate return true if an update doesn't change the spec, like when the controller adds or removes its
+// finalizer, or if the object is being deleted
+func skipUpdate(obj metav1.Object, spec, oldSpec interface{}) bool {
+	return obj.GetDeletionTimestamp() != nil || equality.Semantic.DeepEqual(spec, oldSpec)
+}
+
+// changed return true on creation, or if an update changed a field
+func changed(old runtime.Object, value, oldValue interface{}) bool {
+	return old == nil || !equality.Semantic.DeepEqual(value, oldValue)
+}
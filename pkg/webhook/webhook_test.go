@@ -0,0 +1,73 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/robotinfra/minio-resources-operator/pkg/apis"
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+// newTestClient return a fake Kubernetes client with the MinioServer test and objs
+func newTestClient(t *testing.T, objs ...runtime.Object) client.Client {
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatalf("clientgoscheme.AddToScheme: %v", err)
+	}
+	if err := apis.AddToScheme(s); err != nil {
+		t.Fatalf("apis.AddToScheme: %v", err)
+	}
+	server := &miniov1alpha1.MinioServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec:       miniov1alpha1.MinioServerSpec{Hostname: "minio.example.com", Port: 9000},
+	}
+	return fakeclient.NewFakeClientWithScheme(s, append(objs, server)...)
+}
+
+// validationTest is a validation case, old is nil for a creation
+type validationTest struct {
+	name   string
+	obj    runtime.Object
+	old    runtime.Object
+	fields []string
+}
+
+// runValidationTests fail if validate doesn't return errors for exactly the fields of each test, in order
+func runValidationTests(t *testing.T, c client.Client, validate validateFunc, tests []validationTest) {
+	t.Helper()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errs := validate(context.TODO(), c, test.obj, test.old)
+			if len(errs) != len(test.fields) {
+				t.Fatalf("errors are %v, expected errors on %v", errs, test.fields)
+			}
+			for i, err := range errs {
+				if err.Field != test.fields[i] {
+					t.Errorf("error %d is %v, expected an error on %s", i, err, test.fields[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateServer(t *testing.T) {
+	c := newTestClient(t)
+	path := field.NewPath("spec", "server")
+
+	if errs := validateServer(context.TODO(), c, path, "test"); len(errs) != 0 {
+		t.Errorf("errors are %v, expected none", errs)
+	}
+	if errs := validateServer(context.TODO(), c, path, ""); len(errs) != 1 || errs[0].Type != field.ErrorTypeRequired {
+		t.Errorf("errors are %v, expected a required error", errs)
+	}
+	if errs := validateServer(context.TODO(), c, path, "missing"); len(errs) != 1 || errs[0].Type != field.ErrorTypeNotFound {
+		t.Errorf("errors are %v, expected a not found error", errs)
+	}
+}
@@ -0,0 +1,237 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	miniov1alpha1 "github.com/robotinfra/minio-resources-operator/pkg/apis/minio/v1alpha1"
+)
+
+const (
+	pollInterval = 100 * time.Millisecond
+	pollTimeout  = 30 * time.Second
+
+	testPolicy  = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::e2e-bucket/*"]}]}`
+	otherPolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:GetObject","s3:PutObject"],"Resource":["arn:aws:s3:::e2e-bucket/*"]}]}`
+	userPolicy  = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:*"],"Resource":["arn:aws:s3:::e2e-bucket","arn:aws:s3:::e2e-bucket/*"]}]}`
+)
+
+// eventually poll condition until it returns true, and fail the test on timeout with message
+func eventually(t *testing.T, message string, condition func() bool) {
+	t.Helper()
+	err := wait.PollImmediate(pollInterval, pollTimeout, func() (bool, error) {
+		return condition(), nil
+	})
+	if err != nil {
+		t.Fatalf("%s: %v", message, err)
+	}
+}
+
+// hasCondition return if an object exists with a condition status and reason
+func hasCondition(key types.NamespacedName, obj runtime.Object, conditions func() miniov1alpha1.Conditions, conditionType miniov1alpha1.ConditionType, status corev1.ConditionStatus, reason string) bool {
+	if err := k8sClient.Get(context.TODO(), key, obj); err != nil {
+		return false
+	}
+	condition := conditions().GetCondition(conditionType)
+	return condition != nil && condition.Status == status && condition.Reason == reason
+}
+
+// isDeleted return if an object doesn't exist anymore
+func isDeleted(key types.NamespacedName, obj runtime.Object) bool {
+	return apierrors.IsNotFound(k8sClient.Get(context.TODO(), key, obj))
+}
+
+// update get an object, mutate and update it, retrying on conflicts with the status updates of controllers
+func update(t *testing.T, key types.NamespacedName, obj runtime.Object, mutate func()) {
+	t.Helper()
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := k8sClient.Get(context.TODO(), key, obj); err != nil {
+			return err
+		}
+		mutate()
+		return k8sClient.Update(context.TODO(), obj)
+	})
+	if err != nil {
+		t.Fatalf("k8sClient.Update: %v", err)
+	}
+}
+
+// metricValue return the value of a counter or gauge of the controller-runtime metrics with labels, 0 if not found
+func metricValue(t *testing.T, name string, labels map[string]string) float64 {
+	t.Helper()
+	families, err := ctrlmetrics.Registry.Gather()
+	if err != nil {
+		t.Fatalf("ctrlmetrics.Registry.Gather: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if value, ok := labels[label.GetName()]; ok && value != label.GetValue() {
+					continue metrics
+				}
+			}
+			if m.GetCounter() != nil {
+				return m.GetCounter().GetValue()
+			}
+			return m.GetGauge().GetValue()
+		}
+	}
+	return 0
+}
+
+func TestLifecycle(t *testing.T) {
+	skipWithoutEnvtest(t)
+	ctx := context.TODO()
+
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "e2e-credentials"},
+		StringData: map[string]string{"accessKey": "admin", "secretKey": "adminSecretKey"},
+	}
+	if err := k8sClient.Create(ctx, credentials); err != nil {
+		t.Fatalf("k8sClient.Create: %v", err)
+	}
+
+	// MinioServer goes online
+	server := &miniov1alpha1.MinioServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "e2e"},
+		Spec: miniov1alpha1.MinioServerSpec{
+			Hostname:             "minio.example.com",
+			Port:                 9000,
+			CredentialsSecretRef: &miniov1alpha1.CredentialsSecretReference{Name: credentials.Name, Namespace: credentials.Namespace},
+		},
+	}
+	if err := k8sClient.Create(ctx, server); err != nil {
+		t.Fatalf("k8sClient.Create: %v", err)
+	}
+	serverKey := types.NamespacedName{Name: server.Name}
+	eventually(t, "MinioServer not online", func() bool {
+		return k8sClient.Get(ctx, serverKey, server) == nil && server.Status.Online
+	})
+	if server.Status.Version != "fake" || server.Status.Nodes != 1 || server.Status.Drives != 1 {
+		t.Errorf("MinioServer status is %+v, expected the informations of the fake server", server.Status)
+	}
+
+	// MinioBucket creates a bucket with its policy
+	bucket := &miniov1alpha1.MinioBucket{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "e2e-bucket"},
+		Spec: miniov1alpha1.MinioBucketSpec{
+			Server: server.Name,
+			Name:   "e2e-bucket",
+			Policy: testPolicy,
+		},
+	}
+	if err := k8sClient.Create(ctx, bucket); err != nil {
+		t.Fatalf("k8sClient.Create: %v", err)
+	}
+	bucketKey := types.NamespacedName{Namespace: bucket.Namespace, Name: bucket.Name}
+	bucketConditions := func() miniov1alpha1.Conditions { return bucket.Status.Conditions }
+	eventually(t, "MinioBucket not ready", func() bool {
+		return hasCondition(bucketKey, bucket, bucketConditions, miniov1alpha1.ConditionReady, corev1.ConditionTrue, "Reconciled")
+	})
+	if b, ok := minioServer.GetBucket("e2e-bucket"); !ok || b.Policy != testPolicy {
+		t.Errorf("bucket is %+v, exists %v, expected with policy %q", b, ok, testPolicy)
+	}
+	if len(bucket.GetFinalizers()) == 0 {
+		t.Error("MinioBucket finalizer not added")
+	}
+	if count := metricValue(t, "minio_operator_api_requests_total", map[string]string{"server": server.Name, "operation": "MakeBucket"}); count != 1 {
+		t.Errorf("MakeBucket requests metric is %v, expected 1", count)
+	}
+	if count := metricValue(t, "minio_operator_managed_buckets", map[string]string{"server": server.Name}); count != 1 {
+		t.Errorf("managed buckets metric is %v, expected 1", count)
+	}
+
+	// MinioUser creates a user with its generated policy
+	user := &miniov1alpha1.MinioUser{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "e2e-user"},
+		Spec: miniov1alpha1.MinioUserSpec{
+			Server:    server.Name,
+			AccessKey: "e2e-user",
+			SecretKey: "e2eUserSecretKey",
+			Policy:    userPolicy,
+		},
+	}
+	if err := k8sClient.Create(ctx, user); err != nil {
+		t.Fatalf("k8sClient.Create: %v", err)
+	}
+	userKey := types.NamespacedName{Namespace: user.Namespace, Name: user.Name}
+	userConditions := func() miniov1alpha1.Conditions { return user.Status.Conditions }
+	eventually(t, "MinioUser not ready", func() bool {
+		return hasCondition(userKey, user, userConditions, miniov1alpha1.ConditionReady, corev1.ConditionTrue, "Reconciled")
+	})
+	u, ok := minioServer.GetUser("e2e-user")
+	if !ok || u.SecretKey != "e2eUserSecretKey" || u.PolicyName != user.Status.PolicyName {
+		t.Errorf("user is %+v, exists %v, expected with its secret key and policy %q", u, ok, user.Status.PolicyName)
+	}
+	if p, _ := minioServer.GetPolicy(user.Status.PolicyName); p != userPolicy {
+		t.Errorf("policy is %q, expected %q", p, userPolicy)
+	}
+
+	// MinioBucket update is applied
+	update(t, bucketKey, bucket, func() { bucket.Spec.Policy = otherPolicy })
+	eventually(t, "MinioBucket policy not updated", func() bool {
+		b, _ := minioServer.GetBucket("e2e-bucket")
+		return b.Policy == otherPolicy
+	})
+
+	// MinioBucket waits while the MinioServer is offline
+	minioServer.SetError("CheckLiveness", errors.New("connection refused"))
+	// A spec change triggers a health check without waiting for the probe interval
+	update(t, serverKey, server, func() { server.Spec.RequestTimeout = &metav1.Duration{Duration: time.Minute} })
+	eventually(t, "MinioServer not offline", func() bool {
+		return k8sClient.Get(ctx, serverKey, server) == nil && !server.Status.Online
+	})
+	eventually(t, "MinioBucket not waiting for the MinioServer", func() bool {
+		return hasCondition(bucketKey, bucket, bucketConditions, miniov1alpha1.ConditionReady, corev1.ConditionFalse, "ServerOffline")
+	})
+
+	minioServer.SetError("CheckLiveness", nil)
+	update(t, serverKey, server, func() { server.Spec.RequestTimeout = &metav1.Duration{Duration: 2 * time.Minute} })
+	eventually(t, "MinioBucket not ready after the MinioServer is back online", func() bool {
+		return hasCondition(bucketKey, bucket, bucketConditions, miniov1alpha1.ConditionReady, corev1.ConditionTrue, "Reconciled")
+	})
+
+	// MinioUser and MinioBucket deletion removes the user and the bucket
+	if err := k8sClient.Delete(ctx, user); err != nil {
+		t.Fatalf("k8sClient.Delete: %v", err)
+	}
+	eventually(t, "MinioUser not deleted", func() bool {
+		return isDeleted(userKey, &miniov1alpha1.MinioUser{})
+	})
+	if _, ok := minioServer.GetUser("e2e-user"); ok {
+		t.Error("user not removed")
+	}
+
+	if err := k8sClient.Delete(ctx, bucket); err != nil {
+		t.Fatalf("k8sClient.Delete: %v", err)
+	}
+	eventually(t, "MinioBucket not deleted", func() bool {
+		return isDeleted(bucketKey, &miniov1alpha1.MinioBucket{})
+	})
+	if _, ok := minioServer.GetBucket("e2e-bucket"); ok {
+		t.Error("bucket not removed")
+	}
+
+	// MinioServer deletion
+	if err := k8sClient.Delete(ctx, server); err != nil {
+		t.Fatalf("k8sClient.Delete: %v", err)
+	}
+	eventually(t, "MinioServer not deleted", func() bool {
+		return isDeleted(serverKey, &miniov1alpha1.MinioServer{})
+	})
+}
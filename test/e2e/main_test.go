@@ -0,0 +1,130 @@
+// Package e2e test the CRDs and the controllers together, against the API server of envtest and a fake Minio server.
+// The clients of pkg/minioclient talking to Minio are not used, they are tested against an HTTP stand-in in their package.
+// Tests are skipped when envtest binaries are not installed in the default path, and fail when KUBEBUILDER_ASSETS is
+// set to a directory without them.
+package e2e
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/robotinfra/minio-resources-operator/pkg/apis"
+	"github.com/robotinfra/minio-resources-operator/pkg/controller"
+	"github.com/robotinfra/minio-resources-operator/pkg/metrics"
+	miniofake "github.com/robotinfra/minio-resources-operator/pkg/minioclient/fake"
+)
+
+// defaultAssetsPath is the directory of envtest binaries when KUBEBUILDER_ASSETS is not set
+const defaultAssetsPath = "/usr/local/kubebuilder/bin"
+
+var (
+	// k8sClient is a client of the envtest API server, nil when envtest binaries are not installed
+	k8sClient client.Client
+	// minioServer is the fake Minio server the controllers manage
+	minioServer *miniofake.Server
+)
+
+func TestMain(m *testing.M) {
+	if !assetsInstalled() {
+		if path := os.Getenv("KUBEBUILDER_ASSETS"); path != "" {
+			fmt.Fprintf(os.Stderr, "envtest binaries not found in KUBEBUILDER_ASSETS %s\n", path)
+			os.Exit(1)
+		}
+		fmt.Println("envtest binaries not installed, set KUBEBUILDER_ASSETS to run e2e tests")
+		os.Exit(m.Run())
+	}
+
+	logf.SetLogger(zap.Logger(true))
+
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths: []string{filepath.Join("..", "..", "deploy", "crds")},
+	}
+	stop, err := start(testEnv)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "start: %v\n", err)
+		if err := testEnv.Stop(); err != nil {
+			fmt.Fprintf(os.Stderr, "testEnv.Stop: %v\n", err)
+		}
+		os.Exit(1)
+	}
+
+	code := m.Run()
+
+	close(stop)
+	if err := testEnv.Stop(); err != nil {
+		fmt.Fprintf(os.Stderr, "testEnv.Stop: %v\n", err)
+	}
+	os.Exit(code)
+}
+
+// assetsInstalled return if the binaries of envtest are installed
+func assetsInstalled() bool {
+	path := os.Getenv("KUBEBUILDER_ASSETS")
+	if path == "" {
+		path = defaultAssetsPath
+	}
+	_, err := os.Stat(filepath.Join(path, "kube-apiserver"))
+	return err == nil
+}
+
+// start the API server and the CRDs of testEnv, then the controllers managing a fake Minio server,
+// until stop is closed
+func start(testEnv *envtest.Environment) (chan struct{}, error) {
+	cfg, err := testEnv.Start()
+	if err != nil {
+		return nil, fmt.Errorf("testEnv.Start: %w", err)
+	}
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("clientgoscheme.AddToScheme: %w", err)
+	}
+	if err := apis.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("apis.AddToScheme: %w", err)
+	}
+
+	mgr, err := manager.New(cfg, manager.Options{Scheme: scheme, MetricsBindAddress: "0"})
+	if err != nil {
+		return nil, fmt.Errorf("manager.New: %w", err)
+	}
+	minioServer = miniofake.NewServer()
+	if err := controller.AddToManagerWithClients(mgr, minioServer); err != nil {
+		return nil, fmt.Errorf("controller.AddToManagerWithClients: %w", err)
+	}
+	if err := metrics.RegisterResources(mgr.GetClient()); err != nil {
+		return nil, fmt.Errorf("metrics.RegisterResources: %w", err)
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		if err := mgr.Start(stop); err != nil {
+			fmt.Fprintf(os.Stderr, "mgr.Start: %v\n", err)
+			os.Exit(1)
+		}
+	}()
+
+	// Objects are read from the API server directly, the manager cache being eventually consistent
+	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		close(stop)
+		return nil, fmt.Errorf("client.New: %w", err)
+	}
+	return stop, nil
+}
+
+// skipWithoutEnvtest skip a test when envtest binaries are not installed
+func skipWithoutEnvtest(t *testing.T) {
+	if k8sClient == nil {
+		t.Skip("envtest binaries not installed, set KUBEBUILDER_ASSETS to run e2e tests")
+	}
+}